@@ -1,6 +1,7 @@
 package greenapi
 
 import (
+	"context"
 	"encoding/json"
 )
 
@@ -10,11 +11,32 @@ type AccountCategory struct {
 
 // ------------------------------------------------------------------ GetSettings
 
+type ResponseGetSettings struct {
+	Wid                               string `json:"wid"`
+	CountryInstance                   string `json:"countryInstance"`
+	TypeAccount                       string `json:"typeAccount"`
+	WebhookUrl                        string `json:"webhookUrl"`
+	WebhookUrlToken                   string `json:"webhookUrlToken"`
+	DelaySendMessagesMilliseconds     uint   `json:"delaySendMessagesMilliseconds"`
+	MarkIncomingMessagesReaded        string `json:"markIncomingMessagesReaded"`
+	MarkIncomingMessagesReadedOnReply string `json:"markIncomingMessagesReadedOnReply"`
+	OutgoingWebhook                   string `json:"outgoingWebhook"`
+	OutgoingMessageWebhook            string `json:"outgoingMessageWebhook"`
+	OutgoingAPIMessageWebhook         string `json:"outgoingAPIMessageWebhook"`
+	StateWebhook                      string `json:"stateWebhook"`
+	IncomingWebhook                   string `json:"incomingWebhook"`
+}
+
 // Getting settings of an instance.
 //
 // https://green-api.com/v3/docs/api/account/GetSettings/
 func (c AccountCategory) GetSettings() (*APIResponse, error) {
-	return c.GreenAPI.Request("GET", "getSettings", nil)
+	return c.GetSettingsCtx(context.Background())
+}
+
+// GetSettingsCtx is like GetSettings but uses ctx to cancel or time out the request.
+func (c AccountCategory) GetSettingsCtx(ctx context.Context) (*APIResponse, error) {
+	return c.GreenAPI.Request("GET", "getSettings", nil, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ SetSettings
@@ -146,6 +168,10 @@ func OptionalIncomingWebhook(incomingWebhook bool) SetSettingsOption {
 	}
 }
 
+type ResponseSetSettings struct {
+	SaveSettings bool `json:"saveSettings"`
+}
+
 // Applying settings for an instance.
 //
 // https://green-api.com/v3/docs/api/account/SetSettings/
@@ -163,7 +189,11 @@ func OptionalIncomingWebhook(incomingWebhook bool) SetSettingsOption {
 //	OptionalStateWebhook(stateWebhook bool) <- Get notifications about the instance authorization state change.
 //	OptionalIncomingWebhook(incomingWebhook bool) <- Get notifications about incoming messages and files.
 func (c AccountCategory) SetSettings(options ...SetSettingsOption) (*APIResponse, error) {
+	return c.SetSettingsCtx(context.Background(), options...)
+}
 
+// SetSettingsCtx is like SetSettings but uses ctx to cancel or time out the request.
+func (c AccountCategory) SetSettingsCtx(ctx context.Context, options ...SetSettingsOption) (*APIResponse, error) {
 	r := &RequestSetSettings{}
 	for _, o := range options {
 		err := o(r)
@@ -177,57 +207,118 @@ func (c AccountCategory) SetSettings(options ...SetSettingsOption) (*APIResponse
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "setSettings", jsonData)
+	return c.GreenAPI.Request("POST", "setSettings", jsonData, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ GetStateInstance
 
+// Instance states returned in ResponseGetStateInstance.
+const (
+	StateNotAuthorized = "notAuthorized"
+	StateAuthorized    = "authorized"
+	StateBlocked       = "blocked"
+	StateSleepMode     = "sleepMode"
+	StateStarting      = "starting"
+	StateYellowCard    = "yellowCard"
+)
+
+type ResponseGetStateInstance struct {
+	StateInstance string `json:"stateInstance"`
+}
+
 // Getting state of an instance.
 //
 // https://green-api.com/v3/docs/api/account/GetStateInstance/
 func (c AccountCategory) GetStateInstance() (*APIResponse, error) {
-	return c.GreenAPI.Request("GET", "getStateInstance", nil)
+	return c.GetStateInstanceCtx(context.Background())
+}
+
+// GetStateInstanceCtx is like GetStateInstance but uses ctx to cancel or time out the request.
+func (c AccountCategory) GetStateInstanceCtx(ctx context.Context) (*APIResponse, error) {
+	return c.GreenAPI.Request("GET", "getStateInstance", nil, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ GetStatusInstance
 
+type ResponseGetStatusInstance struct {
+	StatusInstance string `json:"statusInstance"`
+}
+
 // Getting the status of an instance socket connection with MAX.
 //
 // https://green-api.com/v3/docs/api/account/GetStatusInstance/
 func (c AccountCategory) GetStatusInstance() (*APIResponse, error) {
-	return c.GreenAPI.Request("GET", "getStatusInstance", nil)
+	return c.GetStatusInstanceCtx(context.Background())
+}
+
+// GetStatusInstanceCtx is like GetStatusInstance but uses ctx to cancel or time out the request.
+func (c AccountCategory) GetStatusInstanceCtx(ctx context.Context) (*APIResponse, error) {
+	return c.GreenAPI.Request("GET", "getStatusInstance", nil, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ Reboot
 
+type ResponseReboot struct {
+	IsReboot bool `json:"isReboot"`
+}
+
 // Rebooting an instance.
 //
 // https://green-api.com/v3/docs/api/account/Reboot/
 func (c AccountCategory) Reboot() (*APIResponse, error) {
-	return c.GreenAPI.Request("GET", "reboot", nil)
+	return c.RebootCtx(context.Background())
+}
+
+// RebootCtx is like Reboot but uses ctx to cancel or time out the request.
+func (c AccountCategory) RebootCtx(ctx context.Context) (*APIResponse, error) {
+	return c.GreenAPI.Request("GET", "reboot", nil, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ Logout
 
+type ResponseLogout struct {
+	IsLogout bool `json:"isLogout"`
+}
+
 // Logging out an instance.
 //
 // https://green-api.com/v3/docs/api/account/Logout/
 func (c AccountCategory) Logout() (*APIResponse, error) {
-	return c.GreenAPI.Request("GET", "logout", nil)
+	return c.LogoutCtx(context.Background())
+}
+
+// LogoutCtx is like Logout but uses ctx to cancel or time out the request.
+func (c AccountCategory) LogoutCtx(ctx context.Context) (*APIResponse, error) {
+	return c.GreenAPI.Request("GET", "logout", nil, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ StartAuthorization
 
 type RequestStartAuthorization struct {
-	PhoneNumber int `json:"phoneNumber"`
+	PhoneNumber PhoneNumber `json:"phoneNumber"`
+}
+
+type ResponseStartAuthorization struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message,omitempty"`
 }
 
 // Start instance authorization
 //
 // https://green-api.com/v3/en/docs/api/account/StartAuthorization/
 func (c AccountCategory) StartAuthorization(phoneNumber int) (*APIResponse, error) {
+	return c.StartAuthorizationCtx(context.Background(), phoneNumber)
+}
+
+// StartAuthorizationCtx is like StartAuthorization but uses ctx to cancel or time out the request.
+func (c AccountCategory) StartAuthorizationCtx(ctx context.Context, phoneNumber int) (*APIResponse, error) {
+	err := PhoneNumber(phoneNumber).Validate()
+	if err != nil {
+		return nil, err
+	}
+
 	r := &RequestStartAuthorization{
-		PhoneNumber: phoneNumber,
+		PhoneNumber: PhoneNumber(phoneNumber),
 	}
 
 	jsonData, err := json.Marshal(r)
@@ -235,7 +326,7 @@ func (c AccountCategory) StartAuthorization(phoneNumber int) (*APIResponse, erro
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "startAuthorization", jsonData)
+	return c.GreenAPI.Request("POST", "startAuthorization", jsonData, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ SendAuthorizationCode
@@ -244,10 +335,20 @@ type RequestSendAuthorizationCode struct {
 	Code string `json:"code"`
 }
 
+type ResponseSendAuthorizationCode struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
 // Start instance authorization
 //
 // https://green-api.com/v3/en/docs/api/account/StartAuthorization/
 func (c AccountCategory) SendAuthorizationCode(code string) (*APIResponse, error) {
+	return c.SendAuthorizationCodeCtx(context.Background(), code)
+}
+
+// SendAuthorizationCodeCtx is like SendAuthorizationCode but uses ctx to cancel or time out the request.
+func (c AccountCategory) SendAuthorizationCodeCtx(ctx context.Context, code string) (*APIResponse, error) {
 	r := &RequestSendAuthorizationCode{
 		Code: code,
 	}
@@ -257,36 +358,70 @@ func (c AccountCategory) SendAuthorizationCode(code string) (*APIResponse, error
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "sendAuthorizationCode", jsonData)
+	return c.GreenAPI.Request("POST", "sendAuthorizationCode", jsonData, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ SetProfilePicture
 
 type RequestSetProfilePicture struct {
-	File string `json:"file"`
+	File File `json:"-" form:"file"`
+	// Deprecated: Use File. The path of the file for a request encoded with
+	// json.Marshal and sent with Request and WithFormData.
+	FilePath string `json:"file,omitempty" form:"-"`
+}
+
+type ResponseSetProfilePicture struct {
+	SetProfilePicture bool   `json:"setProfilePicture"`
+	UrlAvatar         string `json:"urlAvatar"`
+	Reason            string `json:"reason,omitempty"`
 }
 
 // Setting a profile picture.
 //
 // https://green-api.com/v3/docs/api/account/SetProfilePicture/
 func (c AccountCategory) SetProfilePicture(filepath string) (*APIResponse, error) {
-	r := &RequestSetProfilePicture{
-		File: filepath,
-	}
+	return c.SetProfilePictureCtx(context.Background(), filepath)
+}
 
-	jsonData, err := json.Marshal(r)
-	if err != nil {
-		return nil, err
+// SetProfilePictureCtx is like SetProfilePicture but uses ctx to cancel or time out the request.
+func (c AccountCategory) SetProfilePictureCtx(ctx context.Context, filepath string) (*APIResponse, error) {
+	return c.SetProfilePictureFromCtx(ctx, FileFromPath(filepath))
+}
+
+// Setting a profile picture from any source, see File.
+//
+// https://green-api.com/v3/docs/api/account/SetProfilePicture/
+func (c AccountCategory) SetProfilePictureFrom(file File) (*APIResponse, error) {
+	return c.SetProfilePictureFromCtx(context.Background(), file)
+}
+
+// SetProfilePictureFromCtx is like SetProfilePictureFrom but uses ctx to cancel or time out the request.
+func (c AccountCategory) SetProfilePictureFromCtx(ctx context.Context, file File) (*APIResponse, error) {
+	r := &RequestSetProfilePicture{
+		File: file,
 	}
 
-	return c.GreenAPI.Request("POST", "setProfilePicture", jsonData, WithFormData(true))
+	return uploadMultipart(ctx, c.GreenAPI, "setProfilePicture", r)
 }
 
 // ------------------------------------------------------------------ GetAccountSettings
 
+type ResponseGetAccountSettings struct {
+	Avatar        string `json:"avatar"`
+	Phone         string `json:"phone"`
+	ChatId        string `json:"chatId"`
+	StateInstance string `json:"stateInstance"`
+	DeviceId      string `json:"deviceId"`
+}
+
 // Getting information about the MAX account
 //
 // https://green-api.com/v3/docs/api/account/GetAccountSettings/
 func (c AccountCategory) GetAccountSettings() (*APIResponse, error) {
-	return c.GreenAPI.Request("GET", "getAccountSettings", nil)
+	return c.GetAccountSettingsCtx(context.Background())
+}
+
+// GetAccountSettingsCtx is like GetAccountSettings but uses ctx to cancel or time out the request.
+func (c AccountCategory) GetAccountSettingsCtx(ctx context.Context) (*APIResponse, error) {
+	return c.GreenAPI.Request("GET", "getAccountSettings", nil, WithContext(ctx))
 }
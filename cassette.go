@@ -0,0 +1,327 @@
+package greenapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoInteraction is returned by a replaying cassette for a request that was not recorded.
+var ErrNoInteraction = errors.New("no recorded interaction matches the request")
+
+// Keys of JSON objects whose values are replaced with redactedValue in cassettes.
+var redactedKeys = map[string]bool{
+	"webhookUrlToken":  true,
+	"apiTokenInstance": true,
+}
+
+const redactedValue = "REDACTED"
+
+// Cassette records the requests sent with Request and PartnerRequest and their
+// responses, or replays them without the network. Create it with NewCassette
+// to record or LoadCassette to replay, and pass it with WithCassette:
+//
+//	cassette := greenapi.NewCassette("testdata/send.json")
+//	defer cassette.Save()
+//
+//	GreenAPI, err := greenapi.NewGreenAPI(APIURL, mediaURL, IDInstance, APITokenInstance, greenapi.WithCassette(cassette))
+//
+// APITokenInstance, PartnerToken, webhookUrlToken and apiTokenInstance are
+// redacted before anything is recorded.
+type Cassette struct {
+	path   string
+	record bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     []bool
+}
+
+// Interaction is a request recorded in a cassette with its response.
+type Interaction struct {
+	HTTPMethod string `json:"http_method"`
+	APIMethod  string `json:"api_method"`
+	// URL of the request with the tokens redacted.
+	URL string `json:"url"`
+	// JSON body of the request with its keys sorted and the secrets redacted.
+	Body json.RawMessage `json:"body,omitempty"`
+	// SHA-256 of a body that is not JSON, e.g. an uploaded file.
+	BodySHA256 string `json:"body_sha256,omitempty"`
+
+	StatusCode    int         `json:"status_code,omitempty"`
+	StatusMessage string      `json:"status_message,omitempty"`
+	Header        http.Header `json:"header,omitempty"`
+	// Body of the response if it is JSON, or ResponseText otherwise.
+	ResponseBody json.RawMessage `json:"response_body,omitempty"`
+	ResponseText string          `json:"response_text,omitempty"`
+	// Error of a request that failed without a response.
+	Error string `json:"error,omitempty"`
+}
+
+type cassetteFile struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// NewCassette returns a cassette that records requests. They are sent as usual
+// and written to the file at path by Save.
+func NewCassette(path string) *Cassette {
+	return &Cassette{path: path, record: true}
+}
+
+// LoadCassette reads a cassette written by Save to replay it. Every recorded
+// interaction is replayed once, in order among the requests that match it by
+// HTTP method, API method and body. Other requests fail with ErrNoInteraction.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing cassette %s: %w", path, err)
+	}
+
+	// The bodies are compared and replayed compact, not as indented in the file.
+	for n, i := range file.Interactions {
+		if body, ok := normalizeJSON(i.Body, nil); ok {
+			file.Interactions[n].Body = body
+		}
+		if body, ok := normalizeJSON(i.ResponseBody, nil); ok {
+			file.Interactions[n].ResponseBody = body
+		}
+	}
+
+	return &Cassette{
+		path:         path,
+		interactions: file.Interactions,
+		replayed:     make([]bool, len(file.Interactions)),
+	}, nil
+}
+
+// Record or replay requests with the cassette.
+func WithCassette(cassette *Cassette) ClientOption {
+	return func(c *clientConfig) error {
+		if cassette == nil {
+			return fmt.Errorf("nil cassette")
+		}
+		c.Cassette = cassette
+		return nil
+	}
+}
+
+// Save writes the recorded interactions to the file of the cassette.
+func (c *Cassette) Save() error {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	c.mu.Lock()
+	err := encoder.Encode(cassetteFile{Interactions: c.interactions})
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, buffer.Bytes(), 0o644)
+}
+
+// Interactions returns the recorded interactions.
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Interaction(nil), c.interactions...)
+}
+
+// Unused returns the interactions of a replaying cassette that have not been
+// replayed yet, to check that a test sent all the recorded requests.
+func (c *Cassette) Unused() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.record {
+		return nil
+	}
+
+	var unused []Interaction
+	for n, i := range c.interactions {
+		if !c.replayed[n] {
+			unused = append(unused, i)
+		}
+	}
+	return unused
+}
+
+// replaying reports whether c replays requests. It is false for a nil cassette.
+func (c *Cassette) replaying() bool {
+	return c != nil && !c.record
+}
+
+// roundTrip records the request sent by send, or replays it. secrets maps the
+// tokens of the request to their placeholders.
+func (c *Cassette) roundTrip(r *requestType, HTTPMethod, APIMethod, url string, requestBody []byte, secrets map[string]string, send func() (*APIResponse, error)) (*APIResponse, error) {
+	body := requestBody
+	if r.BodyStream != nil {
+		// The stream is read once to match it, and sent from memory when recording.
+		data, err := io.ReadAll(r.BodyStream)
+		if err != nil {
+			return nil, err
+		}
+		body = data
+		r.BodyStream = bytes.NewReader(data)
+		r.BodySize = int64(len(data))
+	}
+
+	i := Interaction{
+		HTTPMethod: HTTPMethod,
+		APIMethod:  APIMethod,
+		URL:        redactSecrets(url, secrets),
+	}
+	i.Body, i.BodySHA256 = normalizeBody(body, r.SetMimetype.Mimetype, secrets)
+
+	if !c.record {
+		return c.replay(i)
+	}
+
+	resp, err := send()
+	if err != nil {
+		var reqErr *requestError
+		if !errors.As(err, &reqErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return resp, err
+		}
+		i.Error = redactSecrets(reqErr.err.Error(), secrets)
+	} else {
+		i.StatusCode = resp.StatusCode
+		i.StatusMessage = string(resp.StatusMessage)
+		i.Header = resp.Header
+		if normalized, ok := normalizeJSON(resp.Body, secrets); ok {
+			i.ResponseBody = normalized
+		} else {
+			i.ResponseText = redactSecrets(string(resp.Body), secrets)
+		}
+	}
+
+	c.mu.Lock()
+	c.interactions = append(c.interactions, i)
+	c.mu.Unlock()
+
+	return resp, err
+}
+
+func (c *Cassette) replay(request Interaction) (*APIResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for n, i := range c.interactions {
+		if c.replayed[n] || i.HTTPMethod != request.HTTPMethod || i.APIMethod != request.APIMethod ||
+			!bytes.Equal(i.Body, request.Body) || i.BodySHA256 != request.BodySHA256 {
+			continue
+		}
+		c.replayed[n] = true
+
+		if i.Error != "" {
+			return nil, &requestError{err: errors.New(i.Error)}
+		}
+
+		body := []byte(i.ResponseBody)
+		if i.ResponseText != "" {
+			body = []byte(i.ResponseText)
+		}
+		return &APIResponse{
+			StatusCode:    i.StatusCode,
+			StatusMessage: []byte(i.StatusMessage),
+			Body:          body,
+			Header:        i.Header.Clone(),
+			Timestamp:     time.Now(),
+		}, nil
+	}
+
+	body := string(request.Body)
+	if request.BodySHA256 != "" {
+		body = "sha256:" + request.BodySHA256
+	}
+	return nil, fmt.Errorf("%w in cassette %s: %s %s %s", ErrNoInteraction, c.path, request.HTTPMethod, request.APIMethod, body)
+}
+
+// normalizeBody returns a JSON body in its normalized form, or the SHA-256
+// of any other body with the multipart boundary replaced.
+func normalizeBody(body []byte, contentType string, secrets map[string]string) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+	if normalized, ok := normalizeJSON(body, secrets); ok {
+		return normalized, ""
+	}
+
+	// The boundary is random, so it would change the hash of every multipart request.
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["boundary"] != "" {
+		body = bytes.ReplaceAll(body, []byte(params["boundary"]), []byte("boundary"))
+	}
+	sum := sha256.Sum256(body)
+	return nil, hex.EncodeToString(sum[:])
+}
+
+// normalizeJSON sorts the keys of the JSON objects and redacts the secrets.
+func normalizeJSON(data []byte, secrets map[string]string) (json.RawMessage, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil || decoder.More() {
+		return nil, false
+	}
+
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(redactJSON(v, secrets)); err != nil {
+		return nil, false
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), true
+}
+
+func redactJSON(v any, secrets map[string]string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if _, ok := value.(string); ok && redactedKeys[key] {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactJSON(value, secrets)
+			}
+		}
+	case []any:
+		for n, value := range v {
+			v[n] = redactJSON(value, secrets)
+		}
+	case string:
+		return redactSecrets(v, secrets)
+	}
+	return v
+}
+
+func redactSecrets(s string, secrets map[string]string) string {
+	for secret, placeholder := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, placeholder)
+		}
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+package greenapi_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/greenapitest"
+)
+
+func TestCassetteReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	server := greenapitest.NewServer()
+	instance := server.NewInstance()
+	token := instance.APIToken()
+
+	recording := greenapi.NewCassette(path)
+	GreenAPI := newGreenAPI(t, server, instance, greenapi.WithCassette(recording))
+
+	// The responses are replayed normalized, so they are compared by a key.
+	var recorded []string
+	for _, send := range []func() (*greenapi.APIResponse, error){
+		func() (*greenapi.APIResponse, error) { return GreenAPI.Account().GetSettings() },
+		func() (*greenapi.APIResponse, error) { return GreenAPI.Sending().SendMessage("10000000", "First") },
+		func() (*greenapi.APIResponse, error) { return GreenAPI.Sending().SendMessage("10000000", "Second") },
+	} {
+		resp, err := send()
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorded = append(recorded, responseKey(t, resp))
+	}
+	if err := recording.Save(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), token) {
+		t.Error("the cassette contains the token of the instance")
+	}
+
+	replaying, err := greenapi.LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replayed requests are not limited.
+	GreenAPI = newGreenAPI(t, server, instance, greenapi.WithCassette(replaying),
+		greenapi.WithRateLimit(greenapi.Limit{Every: time.Second, Burst: 1}))
+	start := time.Now()
+
+	// Requests with the same body are replayed in the order they were recorded.
+	for _, tt := range []struct {
+		name    string
+		send    func() (*greenapi.APIResponse, error)
+		want    string
+		wantErr error
+	}{
+		{"second message", func() (*greenapi.APIResponse, error) { return GreenAPI.Sending().SendMessage("10000000", "Second") }, recorded[2], nil},
+		{"settings", func() (*greenapi.APIResponse, error) { return GreenAPI.Account().GetSettings() }, recorded[0], nil},
+		{"first message", func() (*greenapi.APIResponse, error) { return GreenAPI.Sending().SendMessage("10000000", "First") }, recorded[1], nil},
+		{"replayed twice", func() (*greenapi.APIResponse, error) { return GreenAPI.Account().GetSettings() }, "", greenapi.ErrNoInteraction},
+		{"not recorded", func() (*greenapi.APIResponse, error) { return GreenAPI.Sending().SendMessage("10000000", "Third") }, "", greenapi.ErrNoInteraction},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.send()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := responseKey(t, resp); got != tt.want {
+				t.Errorf("response %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("replay took %v, want no waiting for the rate limit", elapsed)
+	}
+	if unused := replaying.Unused(); len(unused) != 0 {
+		t.Errorf("%d interactions not replayed", len(unused))
+	}
+}
+
+// responseKey returns idMessage of a sent message or wid of the settings.
+func responseKey(t *testing.T, resp *greenapi.APIResponse) string {
+	t.Helper()
+
+	var body struct {
+		IdMessage string `json:"idMessage"`
+		Wid       string `json:"wid"`
+	}
+	if err := resp.Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return body.IdMessage + body.Wid
+}
@@ -0,0 +1,254 @@
+package greenapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	personalChatSuffix = "@c.us"
+	groupChatSuffix    = "@g.us"
+)
+
+// ------------------------------------------------------------------ PhoneNumber
+
+// PhoneNumber is a phone number in E.164 format without the leading plus:
+// the country code followed by the national number, e.g. 79991234567.
+//
+// It is sent to the API as a JSON number, and can be read from a JSON number
+// or from a string in any format accepted by ParsePhoneNumber.
+type PhoneNumber int64
+
+// ParsePhoneNumber parses a phone number written by a person, e.g.
+// "+7 (999) 123-45-67", "8 999 123 45 67" or "0079991234567". Spaces, dashes,
+// dots and parentheses are ignored. A number with the "00" international prefix
+// is read as if it started with a plus. An 11-digit number starting with 8
+// without a plus is read as a Russian number in the national format,
+// so numbers of other countries should be written with a plus.
+func ParsePhoneNumber(s string) (PhoneNumber, error) {
+	input := strings.TrimSpace(s)
+	international := false
+	if rest, ok := strings.CutPrefix(input, "+"); ok {
+		input, international = rest, true
+	}
+
+	var digits strings.Builder
+	for _, r := range input {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return 0, fmt.Errorf("phone number %q contains %q", s, r)
+		}
+	}
+
+	number := digits.String()
+	if !international {
+		if rest, ok := strings.CutPrefix(number, "00"); ok {
+			number = rest
+		} else if len(number) == 11 && number[0] == '8' {
+			number = "7" + number[1:]
+		}
+	}
+
+	if err := validatePhoneDigits(number); err != nil {
+		return 0, fmt.Errorf("phone number %q: %w", s, err)
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("phone number %q: %w", s, err)
+	}
+	return PhoneNumber(n), nil
+}
+
+// validatePhoneDigits checks the digits of a number in E.164 format:
+// at most 15 digits, with a country code that does not start with 0.
+func validatePhoneDigits(number string) error {
+	if len(number) < 8 || len(number) > 15 {
+		return fmt.Errorf("must have from 8 to 15 digits with the country code, got %v", len(number))
+	}
+	if number[0] == '0' {
+		return fmt.Errorf("country code must not start with 0")
+	}
+	return nil
+}
+
+// Validate checks that the number has from 8 to 15 digits and a country code.
+func (p PhoneNumber) Validate() error {
+	if p <= 0 {
+		return fmt.Errorf("phone number must be positive, got %d", int64(p))
+	}
+	if err := validatePhoneDigits(p.String()); err != nil {
+		return fmt.Errorf("phone number %d: %w", int64(p), err)
+	}
+	return nil
+}
+
+// String returns the digits of the number, e.g. "79991234567".
+func (p PhoneNumber) String() string {
+	return strconv.FormatInt(int64(p), 10)
+}
+
+// E164 returns the number with the leading plus, e.g. "+79991234567".
+func (p PhoneNumber) E164() string {
+	return "+" + p.String()
+}
+
+// ChatId returns the id of the personal chat with the number, e.g. "79991234567@c.us".
+func (p PhoneNumber) ChatId() ChatId {
+	return ChatId(p.String() + personalChatSuffix)
+}
+
+func (p *PhoneNumber) UnmarshalJSON(data []byte) error {
+	var n int64
+	if err := json.Unmarshal(data, &n); err == nil {
+		if err := PhoneNumber(n).Validate(); err != nil {
+			return err
+		}
+		*p = PhoneNumber(n)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("phone number must be a number or a string, got %s", data)
+	}
+	number, err := ParsePhoneNumber(s)
+	if err != nil {
+		return err
+	}
+	*p = number
+	return nil
+}
+
+// ------------------------------------------------------------------ ChatId
+
+// ChatId is the id of a chat: a personal chat "79991234567@c.us",
+// a group chat "120363000000000000@g.us" or a numeric id (lid) "10000000".
+//
+// It is encoded to JSON and form data as a string. Decoding and encoding
+// check the id with ParseChatId, so an invalid id is not sent to the API.
+type ChatId string
+
+// ParseChatId checks and normalizes a chat id. The number of a personal chat
+// id can be written with a plus and separators, e.g. "+7 (999) 123-45-67@c.us",
+// and is normalized to its digits. Phone numbers without a suffix are read
+// as numeric ids; use ParsePhoneNumber and PhoneNumber.ChatId for them.
+func ParseChatId(s string) (ChatId, error) {
+	s = strings.TrimSpace(s)
+
+	if number, ok := strings.CutSuffix(s, personalChatSuffix); ok {
+		if strings.HasPrefix(number, "+") {
+			p, err := ParsePhoneNumber(number)
+			if err != nil {
+				return "", fmt.Errorf("chatId %q: %w", s, err)
+			}
+			return p.ChatId(), nil
+		}
+		number = strings.Map(func(r rune) rune {
+			if r == ' ' || r == '-' || r == '.' || r == '(' || r == ')' {
+				return -1
+			}
+			return r
+		}, number)
+		if !isDigits(number) {
+			return "", fmt.Errorf("chatId %q: the number of a personal chat must consist of digits", s)
+		}
+		return ChatId(number + personalChatSuffix), nil
+	}
+
+	if group, ok := strings.CutSuffix(s, groupChatSuffix); ok {
+		if group == "" || strings.Trim(group, "0123456789-") != "" {
+			return "", fmt.Errorf("chatId %q: the id of a group chat must consist of digits and dashes", s)
+		}
+		return ChatId(s), nil
+	}
+
+	if isDigits(s) {
+		return ChatId(s), nil
+	}
+
+	return "", fmt.Errorf("chatId must end with \"@c.us\" or \"@g.us\" or be numeric\ngot %s instead", s)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// IsGroup reports whether the id is the id of a group chat.
+func (c ChatId) IsGroup() bool {
+	return strings.HasSuffix(string(c), groupChatSuffix)
+}
+
+// IsPersonal reports whether the id is the id of a personal chat with a phone number.
+func (c ChatId) IsPersonal() bool {
+	return strings.HasSuffix(string(c), personalChatSuffix)
+}
+
+// IsLid reports whether the id is a numeric id (lid).
+func (c ChatId) IsLid() bool {
+	return isDigits(string(c))
+}
+
+// PhoneNumber returns the phone number of a personal chat id.
+func (c ChatId) PhoneNumber() (PhoneNumber, bool) {
+	number, ok := strings.CutSuffix(string(c), personalChatSuffix)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || PhoneNumber(n).Validate() != nil {
+		return 0, false
+	}
+	return PhoneNumber(n), true
+}
+
+func (c ChatId) String() string {
+	return string(c)
+}
+
+// MarshalText encodes the normalized id. An empty id is encoded as an empty string.
+func (c ChatId) MarshalText() ([]byte, error) {
+	if c == "" {
+		return []byte{}, nil
+	}
+	id, err := ParseChatId(string(c))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(id), nil
+}
+
+func (c *ChatId) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = ""
+		return nil
+	}
+	id, err := ParseChatId(string(text))
+	if err != nil {
+		return err
+	}
+	*c = id
+	return nil
+}
+
+// toChatIds converts chat ids passed as strings.
+func toChatIds(ids []string) []ChatId {
+	result := make([]ChatId, len(ids))
+	for i, id := range ids {
+		result[i] = ChatId(id)
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package greenapi_test
+
+import (
+	"testing"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+)
+
+func TestParsePhoneNumber(t *testing.T) {
+	for _, tt := range []struct {
+		input   string
+		want    greenapi.PhoneNumber
+		wantErr bool
+	}{
+		{input: "79991234567", want: 79991234567},
+		{input: "+7 (999) 123-45-67", want: 79991234567},
+		{input: "8 999 123 45 67", want: 79991234567},
+		{input: "0079991234567", want: 79991234567},
+		{input: " +44 20.7946.0958 ", want: 442079460958},
+		{input: "+8 999 123 45 67", want: 89991234567},
+		{input: "", wantErr: true},
+		{input: "+7 999 abc", wantErr: true},
+		{input: "1234567", wantErr: true},
+		{input: "1234567890123456", wantErr: true},
+		{input: "+0123456789", wantErr: true},
+	} {
+		got, err := greenapi.ParsePhoneNumber(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParsePhoneNumber(%q) = %v, want an error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParsePhoneNumber(%q) = %v, %v, want %v", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseChatId(t *testing.T) {
+	for _, tt := range []struct {
+		input   string
+		want    greenapi.ChatId
+		wantErr bool
+	}{
+		{input: "79991234567@c.us", want: "79991234567@c.us"},
+		{input: " 79991234567@c.us ", want: "79991234567@c.us"},
+		{input: "+7 (999) 123-45-67@c.us", want: "79991234567@c.us"},
+		{input: "7999-123-45-67@c.us", want: "79991234567@c.us"},
+		{input: "120363000000000000@g.us", want: "120363000000000000@g.us"},
+		{input: "79991234567-1600000000@g.us", want: "79991234567-1600000000@g.us"},
+		{input: "10000000", want: "10000000"},
+		{input: "", wantErr: true},
+		{input: "chat", wantErr: true},
+		{input: "-5", wantErr: true},
+		{input: "+5", wantErr: true},
+		{input: "abc@c.us", wantErr: true},
+		{input: "+7 abc@c.us", wantErr: true},
+		{input: "@g.us", wantErr: true},
+		{input: "group@g.us", wantErr: true},
+	} {
+		got, err := greenapi.ParseChatId(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseChatId(%q) = %q, want an error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseChatId(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,527 @@
+package greenapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// ErrFileTooLarge is returned when a downloaded file exceeds OptionalMaxSize.
+var ErrFileTooLarge = errors.New("file is too large")
+
+const resumeDelay = time.Second
+
+// ------------------------------------------------------------------ DownloadFileTo
+
+type RequestDownloadFileTo struct {
+	MaxSize        int64
+	ResumeAttempts int
+}
+
+type DownloadFileToOption func(*RequestDownloadFileTo) error
+
+// Maximum size of the file in bytes. A larger file is not downloaded, or not
+// downloaded further, and ErrFileTooLarge is returned. Unlimited by default.
+func OptionalMaxSize(maxSize int64) DownloadFileToOption {
+	return func(r *RequestDownloadFileTo) error {
+		if maxSize <= 0 {
+			return fmt.Errorf("max size must be positive, got %v", maxSize)
+		}
+		r.MaxSize = maxSize
+		return nil
+	}
+}
+
+// Number of times an interrupted download is resumed with a Range request, 3 by default.
+func OptionalResumeAttempts(attempts int) DownloadFileToOption {
+	return func(r *RequestDownloadFileTo) error {
+		if attempts < 0 {
+			return fmt.Errorf("resume attempts must not be negative, got %v", attempts)
+		}
+		r.ResumeAttempts = attempts
+		return nil
+	}
+}
+
+// DownloadedFile describes a file downloaded with DownloadFileTo or DownloadFileToPath.
+type DownloadedFile struct {
+	DownloadUrl string
+	// Original file name from the Content-Disposition header or the download URL.
+	FileName string
+	// MIME type detected from the first bytes of the file.
+	MimeType string
+	// Size of the file in bytes.
+	Size int64
+	// Path of the file, set by DownloadFileToPath.
+	Path string
+}
+
+// Downloading a file from a chat and writing its content to w. The content is
+// streamed, and an interrupted download is resumed from the last written byte.
+//
+// https://green-api.com/v3/docs/api/receiving/files/DownloadFile/
+//
+// Add optional arguments by passing these functions:
+//
+//	OptionalMaxSize(maxSize int64) <- Maximum size of the file in bytes. Unlimited by default.
+//	OptionalResumeAttempts(attempts int) <- Number of times an interrupted download is resumed with a Range request, 3 by default.
+func (c ReceivingCategory) DownloadFileTo(ctx context.Context, chatId, idMessage string, w io.Writer, options ...DownloadFileToOption) (*DownloadedFile, error) {
+	r, err := newRequestDownloadFileTo(options)
+	if err != nil {
+		return nil, err
+	}
+
+	downloadUrl, err := c.downloadUrl(ctx, chatId, idMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.downloadUrlTo(ctx, downloadUrl, w, r)
+}
+
+// Downloading a file by its download URL, e.g. the downloadUrl of an incoming file
+// message, and writing its content to w. Works like DownloadFileTo without
+// the DownloadFile request.
+//
+// Accepts the same optional arguments as DownloadFileTo.
+func (c ReceivingCategory) DownloadUrlTo(ctx context.Context, downloadUrl string, w io.Writer, options ...DownloadFileToOption) (*DownloadedFile, error) {
+	r, err := newRequestDownloadFileTo(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.downloadUrlTo(ctx, downloadUrl, w, r)
+}
+
+func (c ReceivingCategory) downloadUrlTo(ctx context.Context, downloadUrl string, w io.Writer, r *RequestDownloadFileTo) (*DownloadedFile, error) {
+	head := &headWriter{w: w}
+	file, err := download(ctx, clientOf(c.GreenAPI), downloadUrl, head, 0, new(string), r)
+	if err != nil {
+		return nil, err
+	}
+	file.MimeType = mimetype.Detect(head.head).String()
+
+	return file, nil
+}
+
+// Downloading a file from a chat to path. If path is a directory, the file is saved
+// in it under the name from the download URL. The content is written to path + ".part"
+// first, so that a download interrupted even by a restart is resumed by the next call.
+// The download URL and the ETag or Last-Modified of the content are kept in
+// path + ".part.info": a part of another download is started over, and a part of
+// a file changed since is replaced with the new content (If-Range).
+//
+// https://green-api.com/v3/docs/api/receiving/files/DownloadFile/
+//
+// Accepts the same optional arguments as DownloadFileTo.
+func (c ReceivingCategory) DownloadFileToPath(ctx context.Context, chatId, idMessage, path string, options ...DownloadFileToOption) (*DownloadedFile, error) {
+	r, err := newRequestDownloadFileTo(options)
+	if err != nil {
+		return nil, err
+	}
+
+	downloadUrl, err := c.downloadUrl(ctx, chatId, idMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		name := urlFileName(downloadUrl)
+		if name == "" {
+			name = idMessage
+		}
+		path = filepath.Join(path, name)
+	}
+
+	partPath := path + ".part"
+	infoPath := partPath + ".info"
+	part, err := os.OpenFile(partPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, validator, err := resumePart(part, infoPath, downloadUrl)
+	if err == nil {
+		err = writePartInfo(infoPath, partInfo{DownloadUrl: downloadUrl, Validator: validator})
+	}
+	if err != nil {
+		part.Close()
+		return nil, err
+	}
+
+	file, err := download(ctx, clientOf(c.GreenAPI), downloadUrl, part, offset, &validator, r)
+	if closeErr := part.Close(); err == nil {
+		err = closeErr
+	}
+	if errors.Is(err, ErrFileTooLarge) {
+		os.Remove(partPath)
+		os.Remove(infoPath)
+		return nil, err
+	}
+	if err != nil {
+		// The validator of the written content lets the next call resume it.
+		writePartInfo(infoPath, partInfo{DownloadUrl: downloadUrl, Validator: validator})
+		return nil, err
+	}
+
+	if err := os.Rename(partPath, path); err != nil {
+		return nil, err
+	}
+	os.Remove(infoPath)
+
+	mtype, err := mimetype.DetectFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file.MimeType = mtype.String()
+	file.Path = path
+
+	return file, nil
+}
+
+// partInfo describes the download that a ".part" file belongs to.
+type partInfo struct {
+	DownloadUrl string `json:"downloadUrl"`
+	// ETag or Last-Modified of the written content, empty if unknown.
+	Validator string `json:"validator,omitempty"`
+}
+
+// resumePart returns the size and the validator of the content in part. A part
+// without the info of downloadUrl is truncated, as it may hold another file.
+func resumePart(part *os.File, infoPath, downloadUrl string) (int64, string, error) {
+	stat, err := part.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+	if stat.Size() == 0 {
+		return 0, "", nil
+	}
+
+	var info partInfo
+	if data, err := os.ReadFile(infoPath); err == nil && json.Unmarshal(data, &info) == nil && info.DownloadUrl == downloadUrl {
+		return stat.Size(), info.Validator, nil
+	}
+	return 0, "", part.Truncate(0)
+}
+
+func writePartInfo(infoPath string, info partInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(infoPath, data, 0o644)
+}
+
+func newRequestDownloadFileTo(options []DownloadFileToOption) (*RequestDownloadFileTo, error) {
+	r := &RequestDownloadFileTo{ResumeAttempts: 3}
+	for _, o := range options {
+		err := o(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (c ReceivingCategory) downloadUrl(ctx context.Context, chatId, idMessage string) (string, error) {
+	resp, err := Decode[ResponseDownloadFile](c.DownloadFileCtx(ctx, chatId, idMessage))
+	if err != nil {
+		return "", err
+	}
+	if resp.DownloadUrl == "" {
+		return "", errors.New("downloadFile returned an empty downloadUrl")
+	}
+	return resp.DownloadUrl, nil
+}
+
+// clientOf returns the HTTP client of api, or the shared one for other implementations.
+func clientOf(api GreenAPIInterface) *apiClient {
+	if a, ok := api.(*GreenAPI); ok {
+		return a.apiClient()
+	}
+	return sharedClient()
+}
+
+// download writes the content at downloadUrl to w, which already holds the first
+// offset bytes, resuming after interruptions. validator holds the ETag or
+// Last-Modified of the written content and is updated from the responses.
+func download(ctx context.Context, client *apiClient, downloadUrl string, w io.Writer, offset int64, validator *string, r *RequestDownloadFileTo) (*DownloadedFile, error) {
+	file := &DownloadedFile{
+		DownloadUrl: downloadUrl,
+		FileName:    urlFileName(downloadUrl),
+	}
+
+	for attempt := 0; ; attempt++ {
+		n, complete, err := fetch(ctx, client, file, w, offset, validator, r)
+		offset = n
+		if err == nil && complete {
+			file.Size = offset
+			return file, nil
+		}
+
+		var interrupted *interruptedError
+		if !errors.As(err, &interrupted) {
+			return nil, err
+		}
+		if attempt >= r.ResumeAttempts {
+			return nil, interrupted.err
+		}
+
+		timer := time.NewTimer(resumeDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// interruptedError is a failure to read the content that a Range request may resume.
+type interruptedError struct {
+	err error
+}
+
+func (e *interruptedError) Error() string {
+	return e.err.Error()
+}
+
+// fetch requests the content from offset and writes it to w. It returns the
+// number of bytes of the file written so far and whether the file is complete.
+// The range is requested only if the content still matches validator (If-Range).
+func fetch(ctx context.Context, client *apiClient, file *DownloadedFile, w io.Writer, offset int64, validator *string, r *RequestDownloadFileTo) (int64, bool, error) {
+	userAgent := client.userAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	header := http.Header{"User-Agent": {userAgent}}
+	if offset > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if *validator != "" {
+			header.Set("If-Range", *validator)
+		}
+	}
+
+	resp, err := client.get(ctx, file.DownloadUrl, header)
+	if err != nil {
+		if ctx.Err() != nil {
+			return offset, false, ctx.Err()
+		}
+		return offset, false, &interruptedError{err: &requestError{err: err}}
+	}
+	defer resp.Body.Close()
+
+	if name := dispositionFileName(resp.Header.Get("Content-Disposition")); name != "" {
+		file.FileName = name
+	}
+
+	// total is the size of the whole file, -1 if unknown.
+	total := int64(-1)
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return offset, false, fmt.Errorf("download: unexpected Content-Range %q for offset %v", resp.Header.Get("Content-Range"), offset)
+		}
+		total = size
+
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The file was downloaded completely before.
+		if _, size, ok := parseContentRange(resp.Header.Get("Content-Range")); ok && size == offset {
+			return offset, true, nil
+		}
+		return offset, false, fmt.Errorf("download: range from %v not satisfiable", offset)
+
+	case resp.StatusCode == http.StatusOK:
+		if resp.ContentLength >= 0 {
+			total = resp.ContentLength
+		}
+		if offset > 0 {
+			// The server ignored the Range header, or the file has changed
+			// since the validator, so the file starts over.
+			if t, ok := w.(interface{ Truncate(int64) error }); ok {
+				if err := t.Truncate(0); err != nil {
+					return offset, false, err
+				}
+				offset = 0
+			} else if header.Get("If-Range") != "" && responseValidator(resp.Header) != *validator {
+				return offset, false, errors.New("download: the file has changed during the download")
+			} else if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+				return offset, false, &interruptedError{err: err}
+			}
+		}
+
+	default:
+		return offset, false, fmt.Errorf("download: unexpected status %s", resp.Status)
+	}
+
+	if v := responseValidator(resp.Header); v != "" {
+		*validator = v
+	}
+
+	if r.MaxSize > 0 && total > r.MaxSize {
+		return offset, false, fmt.Errorf("%w: %v bytes, limit %v", ErrFileTooLarge, total, r.MaxSize)
+	}
+
+	body := io.Reader(resp.Body)
+	if r.MaxSize > 0 {
+		body = io.LimitReader(body, r.MaxSize-offset+1)
+	}
+
+	cw := &countingWriter{w: w}
+	_, err = io.Copy(cw, body)
+	offset += cw.n
+
+	switch {
+	case cw.err != nil:
+		return offset, false, cw.err
+	case r.MaxSize > 0 && offset > r.MaxSize:
+		return offset, false, fmt.Errorf("%w: limit %v", ErrFileTooLarge, r.MaxSize)
+	case err != nil:
+		if ctx.Err() != nil {
+			return offset, false, ctx.Err()
+		}
+		return offset, false, &interruptedError{err: fmt.Errorf("download: %w", err)}
+	case total >= 0 && offset != total:
+		return offset, false, &interruptedError{err: fmt.Errorf("download: got %v of %v bytes: %w", offset, total, io.ErrUnexpectedEOF)}
+	}
+
+	return offset, true, nil
+}
+
+// get requests url with the download client, or with the custom transport,
+// whose response holds the whole content in memory.
+func (c *apiClient) get(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	if c.download != nil {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header = header
+		return c.download.Do(req)
+	}
+
+	resp, err := c.transport.Do(ctx, &HTTPRequest{Method: http.MethodGet, URL: url, Header: header})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	return &http.Response{
+		StatusCode:    resp.StatusCode,
+		Status:        fmt.Sprintf("%d %s", resp.StatusCode, resp.StatusMessage),
+		Header:        resp.Header,
+		Body:          io.NopCloser(bytes.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+	}, nil
+}
+
+// responseValidator returns the strong ETag of the response, or else its Last-Modified.
+func responseValidator(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
+
+// parseContentRange parses "bytes start-end/size". size is -1 for "*".
+func parseContentRange(value string) (start, size int64, ok bool) {
+	rest, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	byteRange, sizeField, found := strings.Cut(rest, "/")
+	if !found {
+		return 0, 0, false
+	}
+
+	size = -1
+	if sizeField != "*" {
+		var err error
+		size, err = strconv.ParseInt(sizeField, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+
+	if byteRange == "*" {
+		return 0, size, true
+	}
+	startField, _, found := strings.Cut(byteRange, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(startField, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, size, true
+}
+
+func dispositionFileName(value string) string {
+	if value == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(value)
+	if err != nil || params["filename"] == "" {
+		return ""
+	}
+	return filepath.Base(params["filename"])
+}
+
+func urlFileName(downloadUrl string) string {
+	u, err := url.Parse(downloadUrl)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		return ""
+	}
+	return name
+}
+
+// countingWriter counts the written bytes and keeps the write error apart from read errors.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	if err != nil {
+		c.err = err
+	}
+	return n, err
+}
+
+// headWriter keeps the first bytes written to w for MIME type detection.
+type headWriter struct {
+	w    io.Writer
+	head []byte
+}
+
+func (h *headWriter) Write(p []byte) (int, error) {
+	if missing := sniffLen - len(h.head); missing > 0 {
+		h.head = append(h.head, p[:min(missing, len(p))]...)
+	}
+	return h.w.Write(p)
+}
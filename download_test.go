@@ -0,0 +1,118 @@
+package greenapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/greenapitest"
+)
+
+func TestDownloadFileToPathResumesPart(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+	GreenAPI := newGreenAPI(t, server, instance)
+
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	other := bytes.Repeat([]byte("abcdefghij"), 1000)
+	idMessage := instance.ReceiveFile("10000000@c.us", "", "report.txt", data)
+
+	downloadUrl, err := greenapi.Decode[greenapi.ResponseDownloadFile](GreenAPI.Receiving().DownloadFile("10000000@c.us", idMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(downloadUrl.DownloadUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+
+	for _, tt := range []struct {
+		name string
+		part []byte
+		// Download URL and validator stored next to the part, no info if the URL is empty.
+		infoUrl   string
+		validator string
+		maxSize   int64
+		wantRange string
+		wantErr   error
+	}{
+		{name: "no part"},
+		{name: "resumed", part: data[:4000], infoUrl: downloadUrl.DownloadUrl, validator: etag, wantRange: "bytes=4000-"},
+		{name: "resumed by last modified", part: data[:4000], infoUrl: downloadUrl.DownloadUrl, validator: lastModified, wantRange: "bytes=4000-"},
+		{name: "complete part", part: data, infoUrl: downloadUrl.DownloadUrl, validator: etag, wantRange: "bytes=10000-"},
+		{name: "changed file", part: other[:4000], infoUrl: downloadUrl.DownloadUrl, validator: `"stale"`, wantRange: "bytes=4000-"},
+		{name: "part of another download", part: other[:4000], infoUrl: server.MediaURL() + "/files/other.txt", validator: etag},
+		{name: "part without info", part: other[:4000]},
+		{name: "too large", maxSize: int64(len(data)) - 1, wantErr: greenapi.ErrFileTooLarge},
+		{name: "too large resumed", part: data[:4000], infoUrl: downloadUrl.DownloadUrl, validator: etag,
+			maxSize: int64(len(data)) - 1, wantRange: "bytes=4000-", wantErr: greenapi.ErrFileTooLarge},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "report.txt")
+			if tt.part != nil {
+				if err := os.WriteFile(path+".part", tt.part, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.infoUrl != "" {
+				info, _ := json.Marshal(map[string]string{"downloadUrl": tt.infoUrl, "validator": tt.validator})
+				if err := os.WriteFile(path+".part.info", info, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var options []greenapi.DownloadFileToOption
+			if tt.maxSize > 0 {
+				options = append(options, greenapi.OptionalMaxSize(tt.maxSize))
+			}
+			sent := len(server.Requests())
+			file, err := GreenAPI.Receiving().DownloadFileToPath(context.Background(), "10000000@c.us", idMessage, path, options...)
+
+			requests := server.Requests()[sent:]
+			media := requests[len(requests)-1]
+			if got := media.Header.Get("Range"); got != tt.wantRange {
+				t.Errorf("Range %q, want %q", got, tt.wantRange)
+			}
+			if got, want := media.Header.Get("If-Range"), tt.validator; tt.wantRange != "" && got != want {
+				t.Errorf("If-Range %q, want %q", got, want)
+			}
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				for _, name := range []string{path, path + ".part", path + ".part.info"} {
+					if _, err := os.Stat(name); !os.IsNotExist(err) {
+						t.Errorf("%s is left after the error", filepath.Base(name))
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(content, data) || file.Size != int64(len(data)) {
+				t.Errorf("downloaded %d bytes, size %d, want the file of %d bytes", len(content), file.Size, len(data))
+			}
+			for _, name := range []string{path + ".part", path + ".part.info"} {
+				if _, err := os.Stat(name); !os.IsNotExist(err) {
+					t.Errorf("%s is left after the download", filepath.Base(name))
+				}
+			}
+		})
+	}
+}
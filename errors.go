@@ -0,0 +1,139 @@
+package greenapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// StatusQuotaExceeded is returned by the API when the instance tariff limits are reached.
+const StatusQuotaExceeded = 466
+
+var (
+	ErrBadRequest            = errors.New("bad request")
+	ErrUnauthorized          = errors.New("unauthorized")
+	ErrForbidden             = errors.New("forbidden")
+	ErrInstanceNotAuthorized = errors.New("instance is not authorized")
+	ErrQuotaExceeded         = errors.New("quota exceeded")
+	ErrRateLimited           = errors.New("rate limited")
+	ErrServerError           = errors.New("server error")
+)
+
+// APIError is a non-2xx response of the API. It is returned along with the
+// response itself when the GreenAPI object is created with WithAPIErrors.
+//
+// Compare it with the sentinel errors using errors.Is:
+//
+//	if errors.Is(err, greenapi.ErrRateLimited) { ... }
+type APIError struct {
+	StatusCode int
+	// API method of the request, e.g. "sendMessage".
+	Method string
+	// Error description parsed from the response body.
+	Message string
+	Body    json.RawMessage
+	// Whether repeating the request later may succeed.
+	Retryable bool
+	Response  *APIResponse
+}
+
+func (e *APIError) Error() string {
+	status := http.StatusText(e.StatusCode)
+	if e.StatusCode == StatusQuotaExceeded {
+		status = "Quota Exceeded"
+	}
+	msg := fmt.Sprintf("%v %s", e.StatusCode, status)
+	if e.Method != "" {
+		msg = e.Method + ": " + msg
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrInstanceNotAuthorized:
+		message := strings.ToLower(e.Message)
+		return strings.Contains(message, "not authorized") || strings.Contains(message, "notauthorized")
+	case ErrQuotaExceeded:
+		return e.StatusCode == StatusQuotaExceeded
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServerError:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// Return an *APIError for every non-2xx response instead of a nil error.
+// The response is still returned as the first value and in APIError.Response.
+func WithAPIErrors(enabled bool) ClientOption {
+	return func(c *clientConfig) error {
+		c.APIErrors = enabled
+		return nil
+	}
+}
+
+// checkResponse turns a non-2xx response into an *APIError if the client is in the error mode.
+func (c *apiClient) checkResponse(APIMethod string, resp *APIResponse, err error) (*APIResponse, error) {
+	if err != nil || !c.apiErrors {
+		return resp, err
+	}
+	if apiErr := newAPIError(APIMethod, resp); apiErr != nil {
+		return resp, apiErr
+	}
+	return resp, nil
+}
+
+// newAPIError returns nil if resp has a 2xx status code.
+func newAPIError(APIMethod string, resp *APIResponse) *APIError {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     APIMethod,
+		Message:    parseErrorMessage(resp.Body),
+		Body:       resp.Body,
+		Retryable:  DefaultRetryableStatus(resp.StatusCode),
+		Response:   resp,
+	}
+}
+
+// parseErrorMessage extracts a description from JSON error bodies like
+// {"message": "..."} or {"error": "..."} and falls back to the raw text.
+func parseErrorMessage(body []byte) string {
+	var parsed struct {
+		Message     string `json:"message"`
+		Error       string `json:"error"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		switch {
+		case parsed.Message != "":
+			return parsed.Message
+		case parsed.Description != "":
+			return parsed.Description
+		case parsed.Error != "":
+			return parsed.Error
+		}
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] != '{' && body[0] != '[' {
+		return string(body)
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+package greenapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/greenapitest"
+)
+
+var sentinels = []error{
+	greenapi.ErrBadRequest,
+	greenapi.ErrUnauthorized,
+	greenapi.ErrForbidden,
+	greenapi.ErrInstanceNotAuthorized,
+	greenapi.ErrQuotaExceeded,
+	greenapi.ErrRateLimited,
+	greenapi.ErrServerError,
+}
+
+func TestAPIErrorIs(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+	GreenAPI := newGreenAPI(t, server, instance, greenapi.WithAPIErrors(true))
+
+	for _, tt := range []struct {
+		name          string
+		fault         greenapitest.Fault
+		wantStatus    int
+		wantMessage   string
+		wantRetryable bool
+		want          []error
+	}{
+		{"bad request", greenapitest.Respond(http.StatusBadRequest, "Validation failed"), http.StatusBadRequest, "Validation failed", false,
+			[]error{greenapi.ErrBadRequest}},
+		{"unauthorized", greenapitest.Respond(http.StatusUnauthorized, "Unauthorized"), http.StatusUnauthorized, "Unauthorized", false,
+			[]error{greenapi.ErrUnauthorized}},
+		{"forbidden", greenapitest.Respond(http.StatusForbidden, "Forbidden"), http.StatusForbidden, "Forbidden", false,
+			[]error{greenapi.ErrForbidden}},
+		{"not authorized", greenapitest.NotAuthorized(), http.StatusBadRequest, "Instance is not authorized", false,
+			[]error{greenapi.ErrBadRequest, greenapi.ErrInstanceNotAuthorized}},
+		{"notAuthorized state", greenapitest.Respond(http.StatusForbidden, "state notAuthorized"), http.StatusForbidden, "state notAuthorized", false,
+			[]error{greenapi.ErrForbidden, greenapi.ErrInstanceNotAuthorized}},
+		{"quota exceeded", greenapitest.Respond(greenapi.StatusQuotaExceeded, "Quota exceeded"), greenapi.StatusQuotaExceeded, "Quota exceeded", false,
+			[]error{greenapi.ErrQuotaExceeded}},
+		{"rate limited", greenapitest.RateLimited(0), http.StatusTooManyRequests, "Too Many Requests", true,
+			[]error{greenapi.ErrRateLimited}},
+		{"internal server error", greenapitest.ServerError(http.StatusInternalServerError), http.StatusInternalServerError, "Internal Server Error", true,
+			[]error{greenapi.ErrServerError}},
+		{"bad gateway", greenapitest.ServerError(http.StatusBadGateway), http.StatusBadGateway, "Bad Gateway", true,
+			[]error{greenapi.ErrServerError}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server.Inject("getSettings", tt.fault)
+
+			resp, err := GreenAPI.Account().GetSettingsCtx(context.Background())
+
+			var apiErr *greenapi.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("got %v, want an *APIError", err)
+			}
+			// The response is returned together with the error.
+			if resp == nil || resp.StatusCode != tt.wantStatus || apiErr.Response != resp {
+				t.Errorf("response %v, want the response of the error with status %d", resp, tt.wantStatus)
+			}
+			if apiErr.StatusCode != tt.wantStatus || apiErr.Method != "getSettings" || apiErr.Retryable != tt.wantRetryable {
+				t.Errorf("error %d %s retryable %v, want %d getSettings retryable %v",
+					apiErr.StatusCode, apiErr.Method, apiErr.Retryable, tt.wantStatus, tt.wantRetryable)
+			}
+			if apiErr.Message != tt.wantMessage {
+				t.Errorf("message %q, want %q", apiErr.Message, tt.wantMessage)
+			}
+			for _, sentinel := range sentinels {
+				want := false
+				for _, w := range tt.want {
+					want = want || w == sentinel
+				}
+				if got := errors.Is(err, sentinel); got != want {
+					t.Errorf("errors.Is(err, %v) = %v, want %v", sentinel, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithoutAPIErrors(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+	GreenAPI := newGreenAPI(t, server, instance)
+
+	server.Inject("getSettings", greenapitest.Respond(greenapi.StatusQuotaExceeded, "Quota exceeded"))
+	resp, err := GreenAPI.Account().GetSettings()
+	if err != nil || resp.StatusCode != greenapi.StatusQuotaExceeded {
+		t.Fatalf("got %v, %v, want the response with a nil error", resp, err)
+	}
+
+	// Decode reports the status as an *APIError anyway.
+	err = resp.Decode(new(greenapi.ResponseGetSettings))
+	if !errors.Is(err, greenapi.ErrQuotaExceeded) {
+		t.Errorf("Decode returned %v, want ErrQuotaExceeded", err)
+	}
+}
+
+// bodyTransport responds to every request with the status and the body.
+type bodyTransport struct {
+	status int
+	body   string
+}
+
+func (t bodyTransport) Do(ctx context.Context, req *greenapi.HTTPRequest) (*greenapi.APIResponse, error) {
+	return &greenapi.APIResponse{StatusCode: t.status, Body: []byte(t.body)}, nil
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		body string
+		want string
+	}{
+		{"message", `{"message": "Instance is not authorized"}`, "Instance is not authorized"},
+		{"description", `{"description": "Validation failed", "error": "Bad Request"}`, "Validation failed"},
+		{"error", `{"error": "Bad Request"}`, "Bad Request"},
+		{"message first", `{"error": "Bad Request", "message": "chatId is required"}`, "chatId is required"},
+		{"text", " Quota exceeded\n", "Quota exceeded"},
+		{"unknown JSON", `{"code": 400}`, ""},
+		{"array", `["Bad Request"]`, ""},
+		{"empty", "", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			GreenAPI, err := greenapi.NewGreenAPI("https://api.example.com", "https://media.example.com", "1101000001", "token",
+				greenapi.WithTransport(bodyTransport{status: http.StatusBadRequest, body: tt.body}), greenapi.WithAPIErrors(true))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = GreenAPI.Account().GetSettings()
+			var apiErr *greenapi.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("got %v, want an *APIError", err)
+			}
+			if apiErr.Message != tt.want {
+				t.Errorf("message %q, want %q", apiErr.Message, tt.want)
+			}
+		})
+	}
+}
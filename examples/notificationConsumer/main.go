@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/notifications"
+)
+
+func main() {
+	GreenAPI := greenapi.GreenAPI{
+		APIURL:           "https://api.green-api.com/v3",
+		MediaURL:         "https://api.green-api.com/v3",
+		IDInstance:       "3100000001",
+		APITokenInstance: "d75b3a66374942c5b3c019c698abc2067e151558acbd412345",
+	}
+
+	consumer, err := notifications.NewConsumer(
+		GreenAPI.Receiving(),
+		notifications.WithReceiveTimeout(20),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	consumer.HandleFunc(notifications.TypeIncomingMessageReceived, func(ctx context.Context, n *notifications.Notification) error {
+		message := n.Body.(*notifications.IncomingMessageReceived)
+
+		fmt.Printf("Message from %s: %s\n\r", message.SenderData.ChatId, message.MessageData.Text())
+		return nil
+	})
+
+	consumer.HandleFunc(notifications.TypeStateInstanceChanged, func(ctx context.Context, n *notifications.Notification) error {
+		fmt.Printf("Instance state: %s\n\r", n.Body.(*notifications.StateInstanceChanged).StateInstance)
+		return nil
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = consumer.Run(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
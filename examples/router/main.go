@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"regexp"
+	"time"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/notifications"
+)
+
+func main() {
+	GreenAPI := greenapi.GreenAPI{
+		APIURL:           "https://api.green-api.com/v3",
+		MediaURL:         "https://api.green-api.com/v3",
+		IDInstance:       "3100000001",
+		APITokenInstance: "d75b3a66374942c5b3c019c698abc2067e151558acbd412345",
+	}
+
+	router := notifications.NewRouter(GreenAPI.Sending())
+	router.Use(
+		notifications.Recovery(),
+		notifications.Logging(log.Default()),
+		notifications.RateLimitPerChat(10, time.Minute),
+	)
+
+	router.OnCommand("/start", func(c *notifications.Context) error {
+		_, err := c.Reply("Hello! Send me \"echo <text>\"")
+		return err
+	}, notifications.PersonalChat())
+
+	router.OnText(regexp.MustCompile(`^echo (.+)`), func(c *notifications.Context) error {
+		_, err := c.Reply(c.Matches[1])
+		return err
+	})
+
+	consumer, err := notifications.NewConsumer(GreenAPI.Receiving())
+	if err != nil {
+		log.Fatal(err)
+	}
+	consumer.HandleDefault(router)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = consumer.Run(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
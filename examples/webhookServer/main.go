@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/green-api/max-api-client-golang/notifications"
+)
+
+func main() {
+	// The token must match webhookUrlToken set with greenapi.OptionalWebhookUrlToken.
+	// To receive notifications by polling instead, replace this constructor
+	// with notifications.NewConsumer(GreenAPI.Receiving()).
+	var receiver notifications.Receiver
+	receiver, err := notifications.NewWebhookServer(":8080", "auth_token")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	receiver.HandleFunc(notifications.TypeIncomingMessageReceived, func(ctx context.Context, n *notifications.Notification) error {
+		message := n.Body.(*notifications.IncomingMessageReceived)
+
+		fmt.Printf("Message from %s: %s\n\r", message.SenderData.ChatId, message.MessageData.Text())
+		return nil
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = receiver.Run(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,95 @@
+package greenapi
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// File is the content of a file to upload. It is read from a path, memory,
+// a reader or an fs.FS only while the request is sent, without temporary files.
+// Name is sent as the file name and can be changed.
+type File struct {
+	Name string
+	open func() (io.ReadCloser, int64, error)
+}
+
+// FileFromPath returns the file at path of the local file system.
+func FileFromPath(filePath string) File {
+	return File{
+		Name: filepath.Base(filePath),
+		open: func() (io.ReadCloser, int64, error) {
+			return openFile(filePath)
+		},
+	}
+}
+
+// FileFromBytes returns a file with the given content, e.g. a PDF rendered in memory.
+func FileFromBytes(name string, data []byte) File {
+	return File{
+		Name: name,
+		open: func() (io.ReadCloser, int64, error) {
+			return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+		},
+	}
+}
+
+// FileFromReader returns a file read from r, e.g. an object storage download.
+// size is the length of the file, or -1 if it is unknown. The reader is not
+// closed and can be read only once, so the file can be uploaded only once.
+func FileFromReader(name string, r io.Reader, size int64) File {
+	return File{
+		Name: name,
+		open: func() (io.ReadCloser, int64, error) {
+			return io.NopCloser(r), size, nil
+		},
+	}
+}
+
+// FileFromFS returns the file with the given name of fsys, e.g. an embed.FS.
+func FileFromFS(fsys fs.FS, name string) File {
+	return File{
+		Name: path.Base(name),
+		open: func() (io.ReadCloser, int64, error) {
+			file, err := fsys.Open(name)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			info, err := file.Stat()
+			if err != nil {
+				file.Close()
+				return nil, 0, err
+			}
+
+			return file, info.Size(), nil
+		},
+	}
+}
+
+func (f File) reader() (io.ReadCloser, int64, error) {
+	if f.open == nil {
+		return nil, 0, errors.New("file has no content, use one of the FileFrom functions")
+	}
+	return f.open()
+}
+
+// openFile opens the file at path and returns its size.
+func openFile(path string) (*os.File, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+
+	return file, info.Size(), nil
+}
@@ -12,4 +12,5 @@ require (
 	github.com/klauspost/compress v1.17.8 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/text v0.15.0 // indirect
 )
@@ -0,0 +1,224 @@
+package greenapitest
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// AnyMethod injects faults into the requests of all methods
+	// that have no faults of their own.
+	AnyMethod = "*"
+	// MethodFiles is the method of the requests for the files served by the media host.
+	MethodFiles = "files"
+)
+
+// Fault is a failure of the API injected with Server.Inject. It is injected once,
+// unless changed with Times or Always.
+type Fault struct {
+	name  string
+	times int
+	// inject responds to the request, or returns false to let the server respond.
+	inject func(f *faultCall) bool
+}
+
+type faultCall struct {
+	server  *Server
+	method  string
+	writer  http.ResponseWriter
+	request *http.Request
+}
+
+type injectedFault struct {
+	Fault
+	// Number of requests left, negative for Always.
+	left int
+	// Id of the instance the fault is injected for, empty for all instances.
+	idInstance string
+}
+
+// Times injects the fault into the next n requests.
+func (f Fault) Times(n int) Fault {
+	f.times = n
+	return f
+}
+
+// Always injects the fault into all requests until ClearFaults.
+func (f Fault) Always() Fault {
+	f.times = -1
+	return f
+}
+
+// String returns the name of the fault, as in Request.Fault.
+func (f Fault) String() string {
+	return f.name
+}
+
+func newFault(name string, inject func(f *faultCall) bool) Fault {
+	return Fault{name: name, times: 1, inject: inject}
+}
+
+// Respond responds with the status code and a JSON error message.
+func Respond(status int, message string) Fault {
+	return newFault(fmt.Sprintf("respond %d", status), func(f *faultCall) bool {
+		writeError(f.writer, status, message)
+		return true
+	})
+}
+
+// RateLimited responds with 429 Too Many Requests and the Retry-After header in seconds.
+func RateLimited(retryAfter time.Duration) Fault {
+	return newFault("rate limited", func(f *faultCall) bool {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		f.writer.Header().Set("Retry-After", strconv.Itoa(seconds))
+		writeError(f.writer, http.StatusTooManyRequests, "Too Many Requests")
+		return true
+	})
+}
+
+// ServerError responds with a 5xx status code, e.g. ServerError(502).Times(3)
+// for a burst of three errors.
+func ServerError(status int) Fault {
+	return newFault(fmt.Sprintf("server error %d", status), func(f *faultCall) bool {
+		writeError(f.writer, status, http.StatusText(status))
+		return true
+	})
+}
+
+// NotAuthorized responds as the API does to an instance that is not authorized:
+// getStateInstance reports notAuthorized and other methods fail.
+// Use Instance.SetState to change the state of the instance itself.
+func NotAuthorized() Fault {
+	return newFault("not authorized", func(f *faultCall) bool {
+		if f.method == "getStateInstance" {
+			writeJSON(f.writer, http.StatusOK, map[string]string{"stateInstance": StateNotAuthorized})
+			return true
+		}
+		writeError(f.writer, http.StatusBadRequest, "Instance is not authorized")
+		return true
+	})
+}
+
+// Delay delays the response of the server by d.
+func Delay(d time.Duration) Fault {
+	return newFault(fmt.Sprintf("delay %v", d), func(f *faultCall) bool {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			return false
+		case <-f.request.Context().Done():
+		case <-f.server.done:
+		}
+		return true
+	})
+}
+
+// Hang does not respond until the client gives up or the server is closed,
+// e.g. a receiveNotification long poll that is stuck.
+func Hang() Fault {
+	return newFault("hang", func(f *faultCall) bool {
+		select {
+		case <-f.request.Context().Done():
+		case <-f.server.done:
+		}
+		return true
+	})
+}
+
+// DropConnection reads the first n bytes of the request body, e.g. a part of an
+// upload, and closes the connection without a response.
+func DropConnection(n int64) Fault {
+	return newFault(fmt.Sprintf("drop connection after %d bytes", n), func(f *faultCall) bool {
+		io.CopyN(io.Discard, f.request.Body, n)
+		// The server closes the connection without logging the panic.
+		panic(http.ErrAbortHandler)
+	})
+}
+
+// Inject adds faults to the requests of the API method, e.g. "sendMessage",
+// MethodFiles or AnyMethod, of all instances. The faults are injected in the order
+// they are added: a fault injected n times is followed by the next one.
+//
+//	server.Inject("sendMessage", greenapitest.RateLimited(2*time.Second), greenapitest.ServerError(503).Times(2))
+func (s *Server) Inject(method string, faults ...Fault) {
+	s.inject("", method, faults)
+}
+
+// Inject adds faults to the requests of the API method of the instance,
+// which are injected before the faults of Server.Inject.
+func (i *Instance) Inject(method string, faults ...Fault) {
+	i.server.inject(i.IdInstance, method, faults)
+}
+
+func (s *Server) inject(idInstance, method string, faults []Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, f := range faults {
+		s.faults[method] = append(s.faults[method], &injectedFault{Fault: f, left: f.times, idInstance: idInstance})
+	}
+}
+
+// ClearFaults removes the faults of all methods.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = make(map[string][]*injectedFault)
+}
+
+// injectFault injects the next fault of the method into the request. It returns
+// the name of the fault, and whether the fault responded, in which case the
+// request is recorded without its body.
+func (s *Server) injectFault(idInstance, method string, w http.ResponseWriter, r *http.Request, media bool) (string, bool) {
+	s.mu.Lock()
+	f := s.nextFault(idInstance, method)
+	if f == nil && method != "" {
+		f = s.nextFault(idInstance, AnyMethod)
+	}
+	s.mu.Unlock()
+
+	if f == nil {
+		return "", false
+	}
+
+	// Recorded also when DropConnection panics.
+	responded := true
+	defer func() {
+		if responded {
+			s.mu.Lock()
+			s.record(Request{IdInstance: idInstance, Method: method, Fault: f.name}, r, media)
+			s.mu.Unlock()
+		}
+	}()
+
+	responded = f.inject(&faultCall{server: s, method: method, writer: w, request: r})
+	return f.name, responded
+}
+
+// nextFault takes the first fault of the method that is left, preferring
+// the faults of the instance.
+func (s *Server) nextFault(idInstance, method string) *Fault {
+	faults := s.faults[method]
+	for _, forInstance := range []bool{true, false} {
+		for n, f := range faults {
+			if (f.idInstance != "") != forInstance || (forInstance && f.idInstance != idInstance) {
+				continue
+			}
+			if f.left > 0 {
+				f.left--
+			}
+			if f.left == 0 {
+				s.faults[method] = append(faults[:n:n], faults[n+1:]...)
+			}
+			return &f.Fault
+		}
+	}
+	return nil
+}
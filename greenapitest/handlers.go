@@ -0,0 +1,693 @@
+package greenapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	greenapi "github.com/green-api/max-api-client-golang"
+)
+
+// handler serves an API method of an instance. It is called with the lock
+// of the server held, except for receiveNotification.
+type handler func(c *call) (any, error)
+
+var handlers = map[string]handler{
+	"getSettings":           getSettings,
+	"setSettings":           setSettings,
+	"getStateInstance":      getStateInstance,
+	"getStatusInstance":     getStatusInstance,
+	"reboot":                reboot,
+	"logout":                logout,
+	"startAuthorization":    startAuthorization,
+	"sendAuthorizationCode": sendAuthorizationCode,
+	"setProfilePicture":     setProfilePicture,
+	"getAccountSettings":    getAccountSettings,
+
+	"sendMessage":      sendMessage,
+	"sendFileByUpload": sendFileByUpload,
+	"sendFileByUrl":    sendFileByUrl,
+	"uploadFile":       uploadFile,
+
+	"receiveNotification": nil, // served by receiveNotification without the lock
+	"deleteNotification":  deleteNotification,
+	"downloadFile":        downloadFile,
+
+	"createGroup":            createGroup,
+	"updateGroupName":        updateGroupName,
+	"getGroupData":           getGroupData,
+	"addGroupParticipant":    addGroupParticipant,
+	"removeGroupParticipant": removeGroupParticipant,
+	"setGroupAdmin":          setGroupAdmin,
+	"removeAdmin":            removeAdmin,
+	"setGroupPicture":        setGroupPicture,
+	"leaveGroup":             leaveGroup,
+
+	"getChatHistory":       getChatHistory,
+	"getMessage":           getMessage,
+	"lastIncomingMessages": lastIncomingMessages,
+	"lastOutgoingMessages": lastOutgoingMessages,
+
+	"showMessagesQueue":  showMessagesQueue,
+	"clearMessagesQueue": clearMessagesQueue,
+
+	"readChat": readChat,
+
+	"CheckAccount":   checkAccount,
+	"getAvatar":      getAvatar,
+	"getContacts":    getContacts,
+	"getContactInfo": getContactInfo,
+}
+
+// decode unmarshals the JSON body of the call into v.
+func (c *call) decode(v any) error {
+	if err := json.Unmarshal(c.body, v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+// form parses the multipart/form-data body of the call.
+func (c *call) form() (*multipart.Form, error) {
+	_, params, err := mime.ParseMediaType(c.request.Header.Get("Content-Type"))
+	if err != nil || params["boundary"] == "" {
+		return nil, errorf(http.StatusBadRequest, "request must be multipart/form-data")
+	}
+	form, err := multipart.NewReader(bytes.NewReader(c.body), params["boundary"]).ReadForm(int64(len(c.body)) + 1)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid form: %v", err)
+	}
+	return form, nil
+}
+
+// formFile returns the name, MIME type and content of the file of the form field.
+func formFile(form *multipart.Form, field string) (string, string, []byte, error) {
+	files := form.File[field]
+	if len(files) == 0 {
+		return "", "", nil, errorf(http.StatusBadRequest, "form has no file %s", field)
+	}
+
+	file, err := files[0].Open()
+	if err != nil {
+		return "", "", nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	mimeType := files[0].Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = detectMimeType(files[0].Filename, data)
+	}
+	return files[0].Filename, mimeType, data, nil
+}
+
+func formValue(form *multipart.Form, field string) string {
+	if values := form.Value[field]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func detectMimeType(fileName string, data []byte) string {
+	detected := mimetype.Detect(data)
+	if detected.Is("application/octet-stream") || detected.Is("text/plain") {
+		if byExtension := mime.TypeByExtension(path.Ext(fileName)); byExtension != "" {
+			return byExtension
+		}
+	}
+	return detected.String()
+}
+
+func parseChatId(chatId string) (greenapi.ChatId, error) {
+	id, err := greenapi.ParseChatId(chatId)
+	if err != nil {
+		return "", errorf(http.StatusBadRequest, "%v", err)
+	}
+	return id, nil
+}
+
+// ------------------------------------------------------------------ Account
+
+func getSettings(c *call) (any, error) {
+	return c.instance.settings, nil
+}
+
+func setSettings(c *call) (any, error) {
+	var r greenapi.RequestSetSettings
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	c.instance.applySettings(r)
+	return greenapi.ResponseSetSettings{SaveSettings: true}, nil
+}
+
+func getStateInstance(c *call) (any, error) {
+	return greenapi.ResponseGetStateInstance{StateInstance: c.instance.state}, nil
+}
+
+func getStatusInstance(c *call) (any, error) {
+	return greenapi.ResponseGetStatusInstance{StatusInstance: "online"}, nil
+}
+
+func reboot(c *call) (any, error) {
+	return greenapi.ResponseReboot{IsReboot: true}, nil
+}
+
+func logout(c *call) (any, error) {
+	c.instance.state = StateNotAuthorized
+	return greenapi.ResponseLogout{IsLogout: true}, nil
+}
+
+func startAuthorization(c *call) (any, error) {
+	var r greenapi.RequestStartAuthorization
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	return greenapi.ResponseStartAuthorization{Status: true}, nil
+}
+
+func sendAuthorizationCode(c *call) (any, error) {
+	var r greenapi.RequestSendAuthorizationCode
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	if r.Code == "" {
+		return greenapi.ResponseSendAuthorizationCode{Message: "code is empty"}, nil
+	}
+	c.instance.state = StateAuthorized
+	return greenapi.ResponseSendAuthorizationCode{Status: true}, nil
+}
+
+func setProfilePicture(c *call) (any, error) {
+	form, err := c.form()
+	if err != nil {
+		return nil, err
+	}
+	name, mimeType, data, err := formFile(form, "file")
+	if err != nil {
+		return nil, err
+	}
+	c.instance.avatar = c.server.addFile(c.instance.IdInstance, name, mimeType, data)
+	return greenapi.ResponseSetProfilePicture{SetProfilePicture: true, UrlAvatar: c.instance.avatar}, nil
+}
+
+func getAccountSettings(c *call) (any, error) {
+	id := greenapi.ChatId(c.instance.Wid)
+	phone, _ := id.PhoneNumber()
+	return greenapi.ResponseGetAccountSettings{
+		Avatar:        c.instance.avatar,
+		Phone:         phone.String(),
+		ChatId:        c.instance.Wid,
+		StateInstance: c.instance.state,
+		DeviceId:      "greenapitest-" + c.instance.IdInstance,
+	}, nil
+}
+
+// ------------------------------------------------------------------ Sending
+
+func sendMessage(c *call) (any, error) {
+	var r greenapi.RequestSendMessage
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	if r.ChatId == "" {
+		return nil, errorf(http.StatusBadRequest, "chatId is required")
+	}
+	if err := greenapi.ValidateMessageLength(r.Message, 20000); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+
+	message := c.instance.addMessage(greenapi.JournalMessage{
+		Type:          "outgoing",
+		TypeMessage:   "textMessage",
+		ChatId:        r.ChatId.String(),
+		SenderId:      c.instance.Wid,
+		TextMessage:   r.Message,
+		StatusMessage: "sent",
+		SendByApi:     true,
+	})
+	return greenapi.ResponseSendMessage{IdMessage: message.IdMessage}, nil
+}
+
+func sendFileByUpload(c *call) (any, error) {
+	form, err := c.form()
+	if err != nil {
+		return nil, err
+	}
+	chatId, err := parseChatId(formValue(form, "chatId"))
+	if err != nil {
+		return nil, err
+	}
+	name, mimeType, data, err := formFile(form, "file")
+	if err != nil {
+		return nil, err
+	}
+	if fileName := formValue(form, "fileName"); fileName != "" {
+		name = fileName
+	}
+
+	message := c.instance.addMessage(greenapi.JournalMessage{
+		Type:          "outgoing",
+		TypeMessage:   typeMessageOf(mimeType),
+		ChatId:        chatId.String(),
+		SenderId:      c.instance.Wid,
+		DownloadUrl:   c.server.addFile(c.instance.IdInstance, name, mimeType, data),
+		Caption:       formValue(form, "caption"),
+		FileName:      name,
+		MimeType:      mimeType,
+		StatusMessage: "sent",
+		SendByApi:     true,
+	})
+	return greenapi.ResponseSendFileByUpload{IdMessage: message.IdMessage, UrlFile: message.DownloadUrl}, nil
+}
+
+func sendFileByUrl(c *call) (any, error) {
+	var r greenapi.RequestSendFileByUrl
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	if r.ChatId == "" || r.UrlFile == "" || r.FileName == "" {
+		return nil, errorf(http.StatusBadRequest, "chatId, urlFile and fileName are required")
+	}
+
+	mimeType := mime.TypeByExtension(path.Ext(r.FileName))
+	message := c.instance.addMessage(greenapi.JournalMessage{
+		Type:          "outgoing",
+		TypeMessage:   typeMessageOf(mimeType),
+		ChatId:        r.ChatId.String(),
+		SenderId:      c.instance.Wid,
+		DownloadUrl:   r.UrlFile,
+		Caption:       r.Caption,
+		FileName:      r.FileName,
+		MimeType:      mimeType,
+		StatusMessage: "sent",
+		SendByApi:     true,
+	})
+	return greenapi.ResponseSendFileByUrl{IdMessage: message.IdMessage}, nil
+}
+
+func uploadFile(c *call) (any, error) {
+	name := c.request.Header.Get("GA-Filename")
+	if name == "" {
+		name = "file"
+	}
+	mimeType := c.request.Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = detectMimeType(name, c.body)
+	}
+	return greenapi.ResponseUploadFile{UrlFile: c.server.addFile(c.instance.IdInstance, name, mimeType, c.body)}, nil
+}
+
+// ------------------------------------------------------------------ Receiving
+
+// receiveNotification waits for a notification until receiveTimeout,
+// limited by WithMaxReceiveWait, and responds with null if there is none.
+func receiveNotification(c *call) (any, error) {
+	wait := 5 * time.Second
+	if seconds, err := strconv.Atoi(c.request.URL.Query().Get("receiveTimeout")); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	}
+	wait = min(wait, c.server.config.MaxReceiveWait)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		c.server.mu.Lock()
+		if c.instance.deleted {
+			c.server.mu.Unlock()
+			return nil, errorf(http.StatusUnauthorized, "Unauthorized")
+		}
+		if len(c.instance.queue) > 0 {
+			first := c.instance.queue[0]
+			c.server.mu.Unlock()
+			return greenapi.ResponseReceiveNotification{ReceiptId: first.ReceiptId, Body: first.Body}, nil
+		}
+		notify := c.instance.notify
+		c.server.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-timer.C:
+			return nil, nil
+		case <-c.request.Context().Done():
+			return nil, c.request.Context().Err()
+		case <-c.server.done:
+			return nil, errorf(http.StatusServiceUnavailable, "server closed")
+		}
+	}
+}
+
+func deleteNotification(c *call) (any, error) {
+	if len(c.args) == 0 {
+		return nil, errorf(http.StatusBadRequest, "receiptId is required")
+	}
+	receiptId, err := strconv.Atoi(c.args[0])
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid receiptId %q", c.args[0])
+	}
+
+	n := slices.IndexFunc(c.instance.queue, func(q queuedNotification) bool {
+		return q.ReceiptId == receiptId
+	})
+	if n < 0 {
+		return greenapi.ResponseDeleteNotification{Result: false}, nil
+	}
+	c.instance.queue = slices.Delete(c.instance.queue, n, n+1)
+	return greenapi.ResponseDeleteNotification{Result: true}, nil
+}
+
+func downloadFile(c *call) (any, error) {
+	var r greenapi.RequestDownloadFile
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	message, ok := c.instance.message(r.ChatId.String(), r.IdMessage)
+	if !ok || message.DownloadUrl == "" {
+		return nil, errorf(http.StatusNotFound, "file of message %s not found", r.IdMessage)
+	}
+	return greenapi.ResponseDownloadFile{DownloadUrl: message.DownloadUrl}, nil
+}
+
+// ------------------------------------------------------------------ Groups
+
+func createGroup(c *call) (any, error) {
+	var r greenapi.RequestCreateGroup
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	if r.GroupName == "" {
+		return nil, errorf(http.StatusBadRequest, "groupName is required")
+	}
+
+	c.server.lastGroup++
+	chatId := fmt.Sprintf("120363%012d@g.us", c.server.lastGroup)
+	now := time.Now().Unix()
+
+	group := &greenapi.ResponseGetGroupData{
+		GroupId:         chatId,
+		Owner:           c.instance.Wid,
+		Subject:         r.GroupName,
+		Creation:        now,
+		SubjectTime:     now,
+		SubjectOwner:    c.instance.Wid,
+		GroupInviteLink: fmt.Sprintf("%s/join/%s", c.server.URL(), randomHex(8)),
+		Participants: []greenapi.GroupParticipant{
+			{Id: c.instance.Wid, IsAdmin: true, IsSuperAdmin: true},
+		},
+	}
+	for _, id := range r.ChatIds {
+		group.Participants = append(group.Participants, greenapi.GroupParticipant{Id: id.String()})
+	}
+	c.instance.groups[chatId] = group
+
+	return greenapi.ResponseCreateGroup{Created: true, ChatId: chatId, GroupInviteLink: group.GroupInviteLink}, nil
+}
+
+// adminGroup returns the group if the instance is its admin.
+func (i *Instance) adminGroup(chatId greenapi.ChatId) (*greenapi.ResponseGetGroupData, bool) {
+	group, ok := i.groups[chatId.String()]
+	if !ok {
+		return nil, false
+	}
+	n := participant(group, i.Wid)
+	return group, n >= 0 && group.Participants[n].IsAdmin
+}
+
+func participant(group *greenapi.ResponseGetGroupData, chatId string) int {
+	return slices.IndexFunc(group.Participants, func(p greenapi.GroupParticipant) bool {
+		return p.Id == chatId
+	})
+}
+
+func updateGroupName(c *call) (any, error) {
+	var r greenapi.RequestUpdateGroupName
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	group, ok := c.instance.adminGroup(r.ChatId)
+	if ok {
+		group.Subject = r.GroupName
+		group.SubjectTime = time.Now().Unix()
+		group.SubjectOwner = c.instance.Wid
+	}
+	return greenapi.ResponseUpdateGroupName{UpdateGroupName: ok}, nil
+}
+
+func getGroupData(c *call) (any, error) {
+	var r greenapi.RequestGetGroupData
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	group, ok := c.instance.groups[r.ChatId.String()]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "group %s not found", r.ChatId)
+	}
+	return group, nil
+}
+
+// modifyParticipant applies f to the group and the index of the participant,
+// -1 if the participant is not in the group. It reports false if the instance
+// is not an admin of the group.
+func modifyParticipant(c *call, f func(group *greenapi.ResponseGetGroupData, n int, participantChatId string) bool) (bool, error) {
+	var r greenapi.RequestModifyGroupParticipant
+	if err := c.decode(&r); err != nil {
+		return false, err
+	}
+	group, ok := c.instance.adminGroup(r.ChatId)
+	if !ok {
+		return false, nil
+	}
+	return f(group, participant(group, r.ParticipantChatId.String()), r.ParticipantChatId.String()), nil
+}
+
+func addGroupParticipant(c *call) (any, error) {
+	ok, err := modifyParticipant(c, func(group *greenapi.ResponseGetGroupData, n int, participantChatId string) bool {
+		if n >= 0 {
+			return false
+		}
+		group.Participants = append(group.Participants, greenapi.GroupParticipant{Id: participantChatId})
+		return true
+	})
+	return greenapi.ResponseAddGroupParticipant{AddParticipant: ok}, err
+}
+
+func removeGroupParticipant(c *call) (any, error) {
+	ok, err := modifyParticipant(c, func(group *greenapi.ResponseGetGroupData, n int, participantChatId string) bool {
+		if n < 0 {
+			return false
+		}
+		group.Participants = slices.Delete(group.Participants, n, n+1)
+		return true
+	})
+	return greenapi.ResponseRemoveGroupParticipant{RemoveParticipant: ok}, err
+}
+
+func setGroupAdmin(c *call) (any, error) {
+	ok, err := modifyParticipant(c, func(group *greenapi.ResponseGetGroupData, n int, participantChatId string) bool {
+		if n < 0 {
+			return false
+		}
+		group.Participants[n].IsAdmin = true
+		return true
+	})
+	return greenapi.ResponseSetGroupAdmin{SetGroupAdmin: ok}, err
+}
+
+func removeAdmin(c *call) (any, error) {
+	ok, err := modifyParticipant(c, func(group *greenapi.ResponseGetGroupData, n int, participantChatId string) bool {
+		if n < 0 || group.Participants[n].IsSuperAdmin {
+			return false
+		}
+		group.Participants[n].IsAdmin = false
+		return true
+	})
+	return greenapi.ResponseRemoveAdmin{RemoveAdmin: ok}, err
+}
+
+func setGroupPicture(c *call) (any, error) {
+	form, err := c.form()
+	if err != nil {
+		return nil, err
+	}
+	chatId, err := parseChatId(formValue(form, "chatId"))
+	if err != nil {
+		return nil, err
+	}
+	name, mimeType, data, err := formFile(form, "file")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := c.instance.adminGroup(chatId); !ok {
+		return greenapi.ResponseSetGroupPicture{Reason: "not an admin of the group"}, nil
+	}
+	return greenapi.ResponseSetGroupPicture{
+		SetGroupPicture: true,
+		UrlAvatar:       c.server.addFile(c.instance.IdInstance, name, mimeType, data),
+	}, nil
+}
+
+func leaveGroup(c *call) (any, error) {
+	var r greenapi.RequestLeaveGroup
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	group, ok := c.instance.groups[r.ChatId.String()]
+	if !ok {
+		return greenapi.ResponseLeaveGroup{LeaveGroup: false}, nil
+	}
+	n := participant(group, c.instance.Wid)
+	if n < 0 {
+		return greenapi.ResponseLeaveGroup{LeaveGroup: false}, nil
+	}
+	group.Participants = slices.Delete(group.Participants, n, n+1)
+	return greenapi.ResponseLeaveGroup{LeaveGroup: true}, nil
+}
+
+// ------------------------------------------------------------------ Journals
+
+func (i *Instance) message(chatId, idMessage string) (greenapi.JournalMessage, bool) {
+	for _, m := range i.messages {
+		if m.ChatId == chatId && m.IdMessage == idMessage {
+			return m, true
+		}
+	}
+	return greenapi.JournalMessage{}, false
+}
+
+func getChatHistory(c *call) (any, error) {
+	var r greenapi.RequestGetChatHistory
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	count := r.Count
+	if count <= 0 {
+		count = 100
+	}
+
+	history := greenapi.ResponseGetChatHistory{}
+	for n := len(c.instance.messages) - 1; n >= 0 && len(history) < count; n-- {
+		if m := c.instance.messages[n]; m.ChatId == r.ChatId.String() {
+			history = append(history, m)
+		}
+	}
+	return history, nil
+}
+
+func getMessage(c *call) (any, error) {
+	var r greenapi.RequestGetMessage
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	message, ok := c.instance.message(r.ChatId.String(), r.IdMessage)
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "message %s not found", r.IdMessage)
+	}
+	return message, nil
+}
+
+// lastMessages returns the messages of the type for the minutes of the query, newest first.
+func lastMessages(c *call, messageType string) (any, error) {
+	minutes := 1440
+	if value := c.request.URL.Query().Get("minutes"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid minutes %q", value)
+		}
+		minutes = parsed
+	}
+	since := time.Now().Add(-time.Duration(minutes) * time.Minute).Unix()
+
+	messages := greenapi.ResponseLastMessages{}
+	for n := len(c.instance.messages) - 1; n >= 0; n-- {
+		if m := c.instance.messages[n]; m.Type == messageType && m.Timestamp >= since {
+			messages = append(messages, m)
+		}
+	}
+	return messages, nil
+}
+
+func lastIncomingMessages(c *call) (any, error) {
+	return lastMessages(c, "incoming")
+}
+
+func lastOutgoingMessages(c *call) (any, error) {
+	return lastMessages(c, "outgoing")
+}
+
+// ------------------------------------------------------------------ Queues
+
+func showMessagesQueue(c *call) (any, error) {
+	return greenapi.ResponseShowMessagesQueue{}, nil
+}
+
+func clearMessagesQueue(c *call) (any, error) {
+	return greenapi.ResponseClearMessagesQueue{IsCleared: true}, nil
+}
+
+// ------------------------------------------------------------------ ReadMark
+
+func readChat(c *call) (any, error) {
+	var r greenapi.RequestReadChat
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	return greenapi.ResponseReadChat{SetRead: true}, nil
+}
+
+// ------------------------------------------------------------------ Service
+
+func checkAccount(c *call) (any, error) {
+	var r greenapi.RequestCheckAccount
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	exists, ok := c.instance.accounts[r.PhoneNumber]
+	if ok && !exists {
+		return greenapi.ResponseCheckAccount{Exist: false}, nil
+	}
+	return greenapi.ResponseCheckAccount{Exist: true, ChatId: r.PhoneNumber.ChatId().String()}, nil
+}
+
+func getAvatar(c *call) (any, error) {
+	var r greenapi.RequestGetAvatar
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	if r.ChatId.String() == c.instance.Wid && c.instance.avatar != "" {
+		return greenapi.ResponseGetAvatar{UrlAvatar: c.instance.avatar, Available: true}, nil
+	}
+	return greenapi.ResponseGetAvatar{}, nil
+}
+
+func getContacts(c *call) (any, error) {
+	contacts := greenapi.ResponseGetContacts{}
+	return append(contacts, c.instance.contacts...), nil
+}
+
+func getContactInfo(c *call) (any, error) {
+	var r greenapi.RequestGetContactInfo
+	if err := c.decode(&r); err != nil {
+		return nil, err
+	}
+	info := greenapi.ResponseGetContactInfo{ChatId: r.ChatId.String()}
+	for _, contact := range c.instance.contacts {
+		if contact.Id == r.ChatId.String() {
+			info.Name, info.ContactName = contact.Name, contact.ContactName
+		}
+	}
+	return info, nil
+}
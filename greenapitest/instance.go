@@ -0,0 +1,361 @@
+package greenapitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+)
+
+// Values of stateInstance.
+const (
+	StateAuthorized    = "authorized"
+	StateNotAuthorized = "notAuthorized"
+	StateBlocked       = "blocked"
+	StateStarting      = "starting"
+)
+
+// Methods that are served whatever the state of the instance is.
+var withoutAuthorization = map[string]bool{
+	"getSettings":           true,
+	"setSettings":           true,
+	"getStateInstance":      true,
+	"getStatusInstance":     true,
+	"reboot":                true,
+	"logout":                true,
+	"startAuthorization":    true,
+	"sendAuthorizationCode": true,
+	"getAccountSettings":    true,
+	"receiveNotification":   true,
+	"deleteNotification":    true,
+}
+
+// Instance is an instance of the fake. Its state is changed by the API methods
+// and can be set up and inspected by the test with the methods below.
+type Instance struct {
+	IdInstance string
+	// Chat id of the account of the instance.
+	Wid string
+
+	id     int64
+	server *Server
+
+	// Guarded by server.mu.
+	token    string
+	name     string
+	created  time.Time
+	deleted  bool
+	state    string
+	settings greenapi.ResponseGetSettings
+	avatar   string
+	messages []greenapi.JournalMessage
+	groups   map[string]*greenapi.ResponseGetGroupData
+	accounts map[greenapi.PhoneNumber]bool
+	contacts []greenapi.Contact
+	queue    []queuedNotification
+	receipt  int
+	// Closed and replaced when a notification is added.
+	notify chan struct{}
+}
+
+type queuedNotification struct {
+	ReceiptId int
+	Body      json.RawMessage
+}
+
+func defaultSettings() greenapi.ResponseGetSettings {
+	return greenapi.ResponseGetSettings{
+		CountryInstance:                   "ru",
+		TypeAccount:                       "trial",
+		MarkIncomingMessagesReaded:        "no",
+		MarkIncomingMessagesReadedOnReply: "no",
+		OutgoingWebhook:                   "yes",
+		OutgoingMessageWebhook:            "yes",
+		OutgoingAPIMessageWebhook:         "yes",
+		StateWebhook:                      "yes",
+		IncomingWebhook:                   "yes",
+	}
+}
+
+// GreenAPI returns an object for the instance that sends requests to the fake.
+func (i *Instance) GreenAPI() *greenapi.GreenAPI {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	return &greenapi.GreenAPI{
+		APIURL:           i.server.URL(),
+		MediaURL:         i.server.MediaURL(),
+		IDInstance:       i.IdInstance,
+		APITokenInstance: i.token,
+	}
+}
+
+// APIToken returns apiTokenInstance, which is changed by RotateToken.
+func (i *Instance) APIToken() string {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	return i.token
+}
+
+// RotateToken changes apiTokenInstance and returns the new token.
+// Requests with the old token get 401 Unauthorized.
+func (i *Instance) RotateToken() string {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	i.token = randomHex(25)
+	return i.token
+}
+
+// State returns stateInstance, StateAuthorized for a new instance.
+func (i *Instance) State() string {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	return i.state
+}
+
+// SetState changes stateInstance. Only the account, authorization and
+// notification methods are served to an instance that is not authorized.
+func (i *Instance) SetState(state string) {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	i.state = state
+}
+
+// Settings returns the settings of the instance.
+func (i *Instance) Settings() greenapi.ResponseGetSettings {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	return i.settings
+}
+
+// SetAccount sets whether CheckAccount finds an account on the number.
+// All numbers have an account by default.
+func (i *Instance) SetAccount(phoneNumber greenapi.PhoneNumber, exists bool) {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	i.accounts[phoneNumber] = exists
+}
+
+// AddContact adds a contact returned by GetContacts and GetContactInfo.
+func (i *Instance) AddContact(contact greenapi.Contact) {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	i.contacts = append(i.contacts, contact)
+}
+
+// Messages returns the messages of the chat in the journal, oldest first.
+// An empty chatId returns the messages of all chats.
+func (i *Instance) Messages(chatId string) []greenapi.JournalMessage {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	var messages []greenapi.JournalMessage
+	for _, m := range i.messages {
+		if chatId == "" || m.ChatId == chatId {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// Group returns the data of a group created by the instance.
+func (i *Instance) Group(chatId string) (greenapi.ResponseGetGroupData, bool) {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	group, ok := i.groups[chatId]
+	if !ok {
+		return greenapi.ResponseGetGroupData{}, false
+	}
+	data := *group
+	data.Participants = slices.Clone(group.Participants)
+	return data, true
+}
+
+// Notifications returns the bodies of the notifications in the queue,
+// that were not deleted with DeleteNotification.
+func (i *Instance) Notifications() []json.RawMessage {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	bodies := make([]json.RawMessage, len(i.queue))
+	for n, q := range i.queue {
+		bodies[n] = q.Body
+	}
+	return bodies
+}
+
+// AddNotification adds a notification to the queue and returns its receipt id.
+// body is the JSON payload of the notification, as json.RawMessage, []byte
+// or a value encoded with json.Marshal.
+func (i *Instance) AddNotification(body any) (int, error) {
+	var raw []byte
+	switch b := body.(type) {
+	case json.RawMessage:
+		raw = b
+	case []byte:
+		raw = b
+	default:
+		var err error
+		raw, err = json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if !json.Valid(raw) {
+		return 0, fmt.Errorf("notification body is not valid JSON")
+	}
+
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	return i.addNotification(raw), nil
+}
+
+// ReceiveText adds an incoming text message from senderId in chatId to the
+// journal and its incomingMessageReceived notification to the queue, and
+// returns the id of the message. An empty senderId is the chat itself.
+func (i *Instance) ReceiveText(chatId, senderId, text string) string {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	message := i.addMessage(greenapi.JournalMessage{
+		Type:        "incoming",
+		TypeMessage: "textMessage",
+		ChatId:      chatId,
+		SenderId:    senderOf(chatId, senderId),
+		TextMessage: text,
+	})
+
+	i.addWebhook("incomingMessageReceived", message, map[string]any{
+		"typeMessage":     "textMessage",
+		"textMessageData": map[string]any{"textMessage": text},
+	})
+	return message.IdMessage
+}
+
+// ReceiveFile adds an incoming file message from senderId in chatId to the
+// journal and its incomingMessageReceived notification to the queue, and
+// returns the id of the message. The file is served by the media host at
+// the downloadUrl of the message.
+func (i *Instance) ReceiveFile(chatId, senderId, fileName string, data []byte) string {
+	i.server.mu.Lock()
+	defer i.server.mu.Unlock()
+
+	mimeType := detectMimeType(fileName, data)
+	message := i.addMessage(greenapi.JournalMessage{
+		Type:        "incoming",
+		TypeMessage: typeMessageOf(mimeType),
+		ChatId:      chatId,
+		SenderId:    senderOf(chatId, senderId),
+		DownloadUrl: i.server.addFile(i.IdInstance, fileName, mimeType, data),
+		FileName:    fileName,
+		MimeType:    mimeType,
+	})
+
+	i.addWebhook("incomingMessageReceived", message, map[string]any{
+		"typeMessage": message.TypeMessage,
+		"fileMessageData": map[string]any{
+			"downloadUrl": message.DownloadUrl,
+			"fileName":    message.FileName,
+			"mimeType":    message.MimeType,
+		},
+	})
+	return message.IdMessage
+}
+
+func senderOf(chatId, senderId string) string {
+	if senderId == "" {
+		return chatId
+	}
+	return senderId
+}
+
+// addMessage sets the id and time of the message and adds it to the journal.
+func (i *Instance) addMessage(m greenapi.JournalMessage) greenapi.JournalMessage {
+	i.server.lastMessage++
+	m.IdMessage = fmt.Sprintf("BAE5%012X", i.server.lastMessage)
+	m.Timestamp = time.Now().Unix()
+	i.messages = append(i.messages, m)
+	return m
+}
+
+func (i *Instance) addWebhook(typeWebhook string, m greenapi.JournalMessage, messageData map[string]any) {
+	raw, _ := json.Marshal(map[string]any{
+		"typeWebhook": typeWebhook,
+		"instanceData": map[string]any{
+			"idInstance":   i.id,
+			"wid":          i.Wid,
+			"typeInstance": "v3",
+		},
+		"timestamp": m.Timestamp,
+		"idMessage": m.IdMessage,
+		"senderData": map[string]any{
+			"chatId":            m.ChatId,
+			"chatName":          "",
+			"sender":            m.SenderId,
+			"senderName":        "",
+			"senderContactName": "",
+		},
+		"messageData": messageData,
+	})
+	i.addNotification(raw)
+}
+
+func (i *Instance) addNotification(body json.RawMessage) int {
+	i.receipt++
+	i.queue = append(i.queue, queuedNotification{ReceiptId: i.receipt, Body: body})
+	close(i.notify)
+	i.notify = make(chan struct{})
+	return i.receipt
+}
+
+func (i *Instance) applySettings(r greenapi.RequestSetSettings) {
+	if r.WebhookUrl != nil {
+		i.settings.WebhookUrl = *r.WebhookUrl
+	}
+	if r.WebhookUrlToken != nil {
+		i.settings.WebhookUrlToken = *r.WebhookUrlToken
+	}
+	if r.DelaySendMessagesMilliseconds != nil {
+		i.settings.DelaySendMessagesMilliseconds = *r.DelaySendMessagesMilliseconds
+	}
+	for _, s := range []struct {
+		value string
+		field *string
+	}{
+		{r.MarkIncomingMessagesReaded, &i.settings.MarkIncomingMessagesReaded},
+		{r.MarkIncomingMessagesReadedOnReply, &i.settings.MarkIncomingMessagesReadedOnReply},
+		{r.OutgoingWebhook, &i.settings.OutgoingWebhook},
+		{r.OutgoingMessageWebhook, &i.settings.OutgoingMessageWebhook},
+		{r.OutgoingAPIMessageWebhook, &i.settings.OutgoingAPIMessageWebhook},
+		{r.StateWebhook, &i.settings.StateWebhook},
+		{r.IncomingWebhook, &i.settings.IncomingWebhook},
+	} {
+		if s.value != "" {
+			*s.field = s.value
+		}
+	}
+}
+
+func typeMessageOf(mimeType string) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return "imageMessage"
+	case strings.HasPrefix(mimeType, "video/"):
+		return "videoMessage"
+	case strings.HasPrefix(mimeType, "audio/"):
+		return "audioMessage"
+	}
+	return "documentMessage"
+}
@@ -0,0 +1,100 @@
+package greenapitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+)
+
+func (s *Server) servePartner(w http.ResponseWriter, r *http.Request, body []byte) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/partner"), "/"), "/")
+	if len(segments) != 2 {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	method, token := segments[0], segments[1]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token != s.PartnerToken {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var result any
+	var err error
+	switch method {
+	case "getInstances":
+		result = s.getInstances()
+	case "createInstance":
+		result, err = s.createInstance(body)
+	case "deleteInstanceAccount":
+		result, err = s.deleteInstanceAccount(body)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown method %s", method))
+		return
+	}
+	writeResult(w, result, err)
+}
+
+func (s *Server) getInstances() greenapi.ResponseGetInstances {
+	instances := greenapi.ResponseGetInstances{}
+	for _, i := range s.instances {
+		instances = append(instances, greenapi.PartnerInstance{
+			IdInstance:       i.id,
+			Name:             i.name,
+			TypeInstance:     "v3",
+			TypeAccount:      i.settings.TypeAccount,
+			TimeCreated:      i.created.UTC().Format(time.DateTime),
+			ApiTokenInstance: i.token,
+			Deleted:          i.deleted,
+			IsPartner:        true,
+		})
+	}
+	return instances
+}
+
+func (s *Server) createInstance(body []byte) (any, error) {
+	var r greenapi.RequestCreateInstance
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+
+	var name string
+	if r.Name != nil {
+		name = *r.Name
+	}
+	i := s.newInstance(name)
+	i.applySettings(r.RequestSetSettings)
+
+	return greenapi.ResponseCreateInstance{
+		IdInstance:       i.id,
+		ApiTokenInstance: i.token,
+		TypeInstance:     "v3",
+	}, nil
+}
+
+func (s *Server) deleteInstanceAccount(body []byte) (any, error) {
+	var r greenapi.RequestDeleteInstanceAccount
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+
+	for _, i := range s.instances {
+		if i.id == int64(r.IdInstance) && !i.deleted {
+			i.deleted = true
+			close(i.notify)
+			i.notify = make(chan struct{})
+			return greenapi.ResponseDeleteInstanceAccount{Code: http.StatusOK}, nil
+		}
+	}
+	return greenapi.ResponseDeleteInstanceAccount{
+		Code:        http.StatusNotFound,
+		Description: fmt.Sprintf("instance %d not found", r.IdInstance),
+	}, nil
+}
@@ -0,0 +1,424 @@
+// Package greenapitest provides an in-process fake of the v3 API for tests
+// of code built on the greenapi package.
+//
+// The fake keeps the state of its instances: sent messages appear in the
+// journals, groups created with CreateGroup are returned by GetGroupData,
+// and notifications added to an instance are received with ReceiveNotification.
+//
+//	server := greenapitest.NewServer()
+//	defer server.Close()
+//
+//	instance := server.NewInstance()
+//	GreenAPI := instance.GreenAPI()
+//
+//	GreenAPI.Sending().SendMessage("10000000", "Hello")
+//	history, err := greenapi.Decode[greenapi.ResponseGetChatHistory](GreenAPI.Journals().GetChatHistory("10000000"))
+package greenapitest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+)
+
+type config struct {
+	MaxReceiveWait time.Duration
+}
+
+type Option func(*config) error
+
+// Maximum time ReceiveNotification waits for a notification, whatever its
+// receiveTimeout is (1 second by default), so that tests do not wait for
+// the timeouts of the real API.
+func WithMaxReceiveWait(d time.Duration) Option {
+	return func(c *config) error {
+		if d <= 0 {
+			return fmt.Errorf("max receive wait must be positive, got %v", d)
+		}
+		c.MaxReceiveWait = d
+		return nil
+	}
+}
+
+// Server is a fake of the v3 API. API serves the methods of instances at
+// /waInstance{idInstance}/{method}/{apiTokenInstance} and the partner methods at
+// /partner/{method}/{partnerToken}. Media serves the methods of instances too,
+// and the files sent to and received by the instances.
+type Server struct {
+	API   *httptest.Server
+	Media *httptest.Server
+
+	PartnerToken string
+
+	config    *config
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu        sync.Mutex
+	instances []*Instance
+	requests  []Request
+	files     map[string]storedFile
+	faults    map[string][]*injectedFault
+	// Counters of instance, message, group and file ids.
+	lastInstance int64
+	lastMessage  int64
+	lastGroup    int64
+	lastFile     int64
+}
+
+// Request is a request received by the server.
+type Request struct {
+	// Id of the instance, empty for partner methods.
+	IdInstance string
+	// API method, e.g. "sendMessage".
+	Method     string
+	HTTPMethod string
+	// Whether the request was sent to the media host.
+	Media  bool
+	URL    *url.URL
+	Header http.Header
+	// Body of the request, nil if an injected fault responded before reading it.
+	Body []byte
+	// Name of the fault injected into the request, if any.
+	Fault string
+}
+
+type storedFile struct {
+	Name     string
+	MimeType string
+	Data     []byte
+	Time     time.Time
+}
+
+// NewServer starts a fake with no instances. It panics if an option is
+// invalid, as httptest.NewServer panics if it cannot listen.
+//
+// Add optional arguments by passing these functions:
+//
+//	WithMaxReceiveWait(d time.Duration) <- Maximum time ReceiveNotification waits for a notification (1 second by default).
+func NewServer(options ...Option) *Server {
+	c := &config{
+		MaxReceiveWait: time.Second,
+	}
+	for _, o := range options {
+		err := o(c)
+		if err != nil {
+			panic("greenapitest: " + err.Error())
+		}
+	}
+
+	s := &Server{
+		PartnerToken: "gac." + randomHex(16),
+		config:       c,
+		done:         make(chan struct{}),
+		files:        make(map[string]storedFile),
+		faults:       make(map[string][]*injectedFault),
+	}
+	s.API = httptest.NewServer(s.handler(false))
+	s.Media = httptest.NewServer(s.handler(true))
+	return s
+}
+
+// Close shuts down the API and media servers. Requests blocked by Hang return.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.API.Close()
+	s.Media.Close()
+}
+
+// URL returns the APIURL of the fake.
+func (s *Server) URL() string {
+	return s.API.URL
+}
+
+// MediaURL returns the MediaURL of the fake.
+func (s *Server) MediaURL() string {
+	return s.Media.URL
+}
+
+// PartnerURL returns the URL of the partner methods of the fake.
+func (s *Server) PartnerURL() string {
+	return s.API.URL + "/partner"
+}
+
+// GreenAPIPartner returns a partner object with the token of the fake.
+func (s *Server) GreenAPIPartner() *greenapi.GreenAPIPartner {
+	return &greenapi.GreenAPIPartner{
+		PartnerToken: s.PartnerToken,
+		PartnerURL:   s.PartnerURL(),
+	}
+}
+
+// NewInstance adds an authorized instance.
+func (s *Server) NewInstance() *Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.newInstance("")
+}
+
+// Instance returns the instance with the given id, or nil.
+func (s *Server) Instance(idInstance string) *Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.instance(idInstance)
+}
+
+// Instances returns the instances in the order they were created, including deleted ones.
+func (s *Server) Instances() []*Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*Instance(nil), s.instances...)
+}
+
+// Requests returns the requests received by the server in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) newInstance(name string) *Instance {
+	s.lastInstance++
+	id := 3100000000 + s.lastInstance
+
+	i := &Instance{
+		IdInstance: strconv.FormatInt(id, 10),
+		id:         id,
+		token:      randomHex(25),
+		Wid:        fmt.Sprintf("7999%07d@c.us", s.lastInstance),
+		server:     s,
+		name:       name,
+		created:    time.Now(),
+		state:      StateAuthorized,
+		settings:   defaultSettings(),
+		groups:     make(map[string]*greenapi.ResponseGetGroupData),
+		accounts:   make(map[greenapi.PhoneNumber]bool),
+		notify:     make(chan struct{}),
+	}
+	i.settings.Wid = i.Wid
+	s.instances = append(s.instances, i)
+	return i
+}
+
+func (s *Server) instance(idInstance string) *Instance {
+	for _, i := range s.instances {
+		if i.IdInstance == idInstance {
+			return i
+		}
+	}
+	return nil
+}
+
+// addFile stores a file served by the media host and returns its URL.
+func (s *Server) addFile(idInstance, name, mimeType string, data []byte) string {
+	s.lastFile++
+	filePath := fmt.Sprintf("/files/%s/%d/%s", idInstance, s.lastFile, url.PathEscape(path.Base(name)))
+	s.files[filePath] = storedFile{Name: path.Base(name), MimeType: mimeType, Data: data, Time: time.Now()}
+	return s.Media.URL + filePath
+}
+
+func (s *Server) handler(media bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idInstance, method := route(r, media)
+
+		fault, responded := s.injectFault(idInstance, method, w, r, media)
+		if responded {
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		s.mu.Lock()
+		s.record(Request{IdInstance: idInstance, Method: method, Body: body, Fault: fault}, r, media)
+		s.mu.Unlock()
+
+		switch {
+		case method == MethodFiles:
+			s.serveFile(w, r)
+		case !media && strings.HasPrefix(r.URL.Path, "/partner/"):
+			s.servePartner(w, r, body)
+		default:
+			s.serveInstance(w, r, body)
+		}
+	})
+}
+
+// route returns the instance id and the API method of the request path.
+func route(r *http.Request, media bool) (idInstance, method string) {
+	if media && strings.HasPrefix(r.URL.Path, "/files/") {
+		return "", MethodFiles
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if !media && segments[0] == "partner" && len(segments) > 1 {
+		return "", segments[1]
+	}
+	if id, ok := strings.CutPrefix(segments[0], "waInstance"); ok && len(segments) > 1 {
+		return id, segments[1]
+	}
+	return "", ""
+}
+
+func (s *Server) record(request Request, r *http.Request, media bool) {
+	request.HTTPMethod = r.Method
+	request.Media = media
+	request.URL = r.URL
+	request.Header = r.Header.Clone()
+	s.requests = append(s.requests, request)
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	file, ok := s.files[r.URL.EscapedPath()]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// The ETag lets clients resume a download with If-Range.
+	sum := sha256.Sum256(file.Data)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+	w.Header().Set("Content-Type", file.MimeType)
+	http.ServeContent(w, r, file.Name, file.Time, bytes.NewReader(file.Data))
+}
+
+// call is a request to a method of an instance.
+type call struct {
+	server   *Server
+	instance *Instance
+	request  *http.Request
+	body     []byte
+	// Path segments after the token, e.g. the receipt id of deleteNotification.
+	args []string
+}
+
+// httpError is a non-2xx response of a handler.
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func errorf(status int, format string, args ...any) error {
+	return &httpError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) serveInstance(w http.ResponseWriter, r *http.Request, body []byte) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	idInstance, ok := strings.CutPrefix(segments[0], "waInstance")
+	if !ok || len(segments) < 3 {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	method, token := segments[1], segments[2]
+
+	handle, ok := handlers[method]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown method %s", method))
+		return
+	}
+
+	s.mu.Lock()
+	instance := s.instance(idInstance)
+	authorized := instance != nil && instance.token == token
+	s.mu.Unlock()
+
+	if !authorized {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	c := &call{
+		server:   s,
+		instance: instance,
+		request:  r,
+		body:     body,
+		args:     segments[3:],
+	}
+
+	// receiveNotification waits without the lock.
+	if method == "receiveNotification" {
+		result, err := receiveNotification(c)
+		writeResult(w, result, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if instance.deleted {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if instance.state != StateAuthorized && !withoutAuthorization[method] {
+		writeError(w, http.StatusBadRequest, "Instance is not authorized")
+		return
+	}
+
+	result, err := handle(c)
+	writeResult(w, result, err)
+}
+
+func writeResult(w http.ResponseWriter, result any, err error) {
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		writeError(w, httpErr.status, httpErr.message)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,170 @@
+package greenapitest_test
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/greenapitest"
+)
+
+func TestJournals(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+	GreenAPI := instance.GreenAPI()
+
+	var sent []string
+	for _, m := range []struct{ chatId, text string }{
+		{"10000000@c.us", "First"},
+		{"20000000@c.us", "Other chat"},
+		{"10000000@c.us", "Second"},
+	} {
+		resp, err := greenapi.Decode[greenapi.ResponseSendMessage](GreenAPI.Sending().SendMessage(m.chatId, m.text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sent = append(sent, resp.IdMessage)
+	}
+	received := instance.ReceiveText("10000000@c.us", "", "Reply")
+
+	for _, tt := range []struct {
+		name string
+		get  func() (*greenapi.APIResponse, error)
+		// Ids of the messages, newest first.
+		want []string
+	}{
+		{"chat history", func() (*greenapi.APIResponse, error) {
+			return GreenAPI.Journals().GetChatHistory("10000000@c.us")
+		}, []string{received, sent[2], sent[0]}},
+		{"chat history count", func() (*greenapi.APIResponse, error) {
+			return GreenAPI.Journals().GetChatHistory("10000000@c.us", greenapi.OptionalCount(2))
+		}, []string{received, sent[2]}},
+		{"other chat history", func() (*greenapi.APIResponse, error) {
+			return GreenAPI.Journals().GetChatHistory("20000000@c.us")
+		}, []string{sent[1]}},
+		{"last outgoing", func() (*greenapi.APIResponse, error) {
+			return GreenAPI.Journals().LastOutgoingMessages()
+		}, []string{sent[2], sent[1], sent[0]}},
+		{"last incoming", func() (*greenapi.APIResponse, error) {
+			return GreenAPI.Journals().LastIncomingMessages()
+		}, []string{received}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := greenapi.Decode[[]greenapi.JournalMessage](tt.get())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, m := range *messages {
+				ids = append(ids, m.IdMessage)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("messages %q, want %q", ids, tt.want)
+			}
+		})
+	}
+
+	message, err := greenapi.Decode[greenapi.JournalMessage](GreenAPI.Journals().GetMessage("10000000@c.us", sent[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Type != "outgoing" || message.TextMessage != "Second" || !message.SendByApi {
+		t.Errorf("GetMessage returned %+v, want the outgoing message \"Second\" sent by the API", message)
+	}
+	if got := instance.Messages("10000000@c.us"); len(got) != 3 || got[0].IdMessage != sent[0] {
+		t.Errorf("Instance.Messages returned %d messages, want 3 oldest first", len(got))
+	}
+}
+
+func TestGroups(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+	GreenAPI := instance.GreenAPI()
+
+	created, err := greenapi.Decode[greenapi.ResponseCreateGroup](GreenAPI.Groups().CreateGroup("Team", []string{"10000000@c.us"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.Created || created.ChatId == "" {
+		t.Fatalf("CreateGroup returned %+v", created)
+	}
+
+	if _, err := GreenAPI.Groups().UpdateGroupName(created.ChatId, "Renamed"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GreenAPI.Groups().AddGroupParticipant(created.ChatId, "20000000@c.us"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GreenAPI.Groups().SetGroupAdmin(created.ChatId, "10000000@c.us"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := greenapi.Decode[greenapi.ResponseGetGroupData](GreenAPI.Groups().GetGroupData(created.ChatId))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Subject != "Renamed" || data.Owner != instance.Wid || data.GroupInviteLink != created.GroupInviteLink {
+		t.Errorf("group data %+v, want the renamed group of the instance", data)
+	}
+	want := []greenapi.GroupParticipant{
+		{Id: instance.Wid, IsAdmin: true, IsSuperAdmin: true},
+		{Id: "10000000@c.us", IsAdmin: true},
+		{Id: "20000000@c.us"},
+	}
+	if !slices.Equal(data.Participants, want) {
+		t.Errorf("participants %+v, want %+v", data.Participants, want)
+	}
+
+	if group, ok := instance.Group(created.ChatId); !ok || group.Subject != "Renamed" {
+		t.Errorf("Instance.Group returned %+v, %v", group, ok)
+	}
+
+	resp, err := GreenAPI.Groups().GetGroupData("120363999999999999@g.us")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status %d for an unknown group, want 404", resp.StatusCode)
+	}
+}
+
+func TestPartnerInstances(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	partner := server.GreenAPIPartner()
+
+	created, err := greenapi.Decode[greenapi.ResponseCreateInstance](partner.Partner().CreateInstance(greenapi.OptionalName("bot")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instances, err := greenapi.Decode[greenapi.ResponseGetInstances](partner.Partner().GetInstances())
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := slices.IndexFunc(*instances, func(p greenapi.PartnerInstance) bool { return p.IdInstance == created.IdInstance })
+	if i < 0 {
+		t.Fatalf("GetInstances does not return the created instance %d", created.IdInstance)
+	}
+	if got := (*instances)[i]; got.Name != "bot" || got.ApiTokenInstance != created.ApiTokenInstance || got.Deleted {
+		t.Errorf("GetInstances returned %+v", got)
+	}
+
+	instance := server.Instance(strconv.FormatInt(created.IdInstance, 10))
+	if instance == nil || instance.APIToken() != created.ApiTokenInstance {
+		t.Fatalf("the created instance is not served with its token")
+	}
+	GreenAPI := instance.GreenAPI()
+	if resp, err := GreenAPI.Account().GetSettings(); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("GetSettings of the created instance: %v, %v", resp, err)
+	}
+
+	instance.RotateToken()
+	if resp, err := GreenAPI.Account().GetSettings(); err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GetSettings with the old token: %v, %v, want 401", resp, err)
+	}
+}
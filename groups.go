@@ -1,6 +1,9 @@
 package greenapi
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/json"
+)
 
 type GroupsCategory struct {
 	GreenAPI GreenAPIInterface
@@ -10,13 +13,24 @@ type GroupsCategory struct {
 
 type RequestCreateGroup struct {
 	GroupName string   `json:"groupName"`
-	ChatIds   []string `json:"chatIds"`
+	ChatIds   []ChatId `json:"chatIds"`
+}
+
+type ResponseCreateGroup struct {
+	Created         bool   `json:"created"`
+	ChatId          string `json:"chatId"`
+	GroupInviteLink string `json:"groupInviteLink"`
 }
 
 // Creating a group chat.
 //
 // https://green-api.com/v3/docs/api/groups/CreateGroup/
 func (c GroupsCategory) CreateGroup(groupName string, chatIds []string) (*APIResponse, error) {
+	return c.CreateGroupCtx(context.Background(), groupName, chatIds)
+}
+
+// CreateGroupCtx is like CreateGroup but uses ctx to cancel or time out the request.
+func (c GroupsCategory) CreateGroupCtx(ctx context.Context, groupName string, chatIds []string) (*APIResponse, error) {
 	for _, chatId := range chatIds {
 		err := ValidateChatId(chatId)
 		if err != nil {
@@ -26,7 +40,7 @@ func (c GroupsCategory) CreateGroup(groupName string, chatIds []string) (*APIRes
 
 	r := &RequestCreateGroup{
 		GroupName: groupName,
-		ChatIds:   chatIds,
+		ChatIds:   toChatIds(chatIds),
 	}
 
 	jsonData, err := json.Marshal(r)
@@ -34,27 +48,36 @@ func (c GroupsCategory) CreateGroup(groupName string, chatIds []string) (*APIRes
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "createGroup", jsonData)
+	return c.GreenAPI.Request("POST", "createGroup", jsonData, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ UpdateGroupName
 
 type RequestUpdateGroupName struct {
-	ChatId    string `json:"chatId"`
+	ChatId    ChatId `json:"chatId"`
 	GroupName string `json:"groupName"`
 }
 
+type ResponseUpdateGroupName struct {
+	UpdateGroupName bool `json:"updateGroupName"`
+}
+
 // Change a group chat name.
 //
 // https://green-api.com/v3/docs/api/groups/UpdateGroupName/
 func (c GroupsCategory) UpdateGroupName(chatId, groupName string) (*APIResponse, error) {
+	return c.UpdateGroupNameCtx(context.Background(), chatId, groupName)
+}
+
+// UpdateGroupNameCtx is like UpdateGroupName but uses ctx to cancel or time out the request.
+func (c GroupsCategory) UpdateGroupNameCtx(ctx context.Context, chatId, groupName string) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestUpdateGroupName{
-		ChatId:    chatId,
+		ChatId:    ChatId(chatId),
 		GroupName: groupName,
 	}
 
@@ -63,26 +86,48 @@ func (c GroupsCategory) UpdateGroupName(chatId, groupName string) (*APIResponse,
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "updateGroupName", jsonData)
+	return c.GreenAPI.Request("POST", "updateGroupName", jsonData, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ GetGroupData
 
 type RequestGetGroupData struct {
-	ChatId string `json:"chatId"`
+	ChatId ChatId `json:"chatId"`
+}
+
+type GroupParticipant struct {
+	Id           string `json:"id"`
+	IsAdmin      bool   `json:"isAdmin"`
+	IsSuperAdmin bool   `json:"isSuperAdmin"`
+}
+
+type ResponseGetGroupData struct {
+	GroupId         string             `json:"groupId"`
+	Owner           string             `json:"owner"`
+	Subject         string             `json:"subject"`
+	Creation        int64              `json:"creation"`
+	Participants    []GroupParticipant `json:"participants"`
+	SubjectTime     int64              `json:"subjectTime"`
+	SubjectOwner    string             `json:"subjectOwner"`
+	GroupInviteLink string             `json:"groupInviteLink"`
 }
 
 // Getting a group chat data
 //
 // https://green-api.com/v3/docs/api/groups/GetGroupData/
 func (c GroupsCategory) GetGroupData(chatId string) (*APIResponse, error) {
+	return c.GetGroupDataCtx(context.Background(), chatId)
+}
+
+// GetGroupDataCtx is like GetGroupData but uses ctx to cancel or time out the request.
+func (c GroupsCategory) GetGroupDataCtx(ctx context.Context, chatId string) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestGetGroupData{
-		ChatId: chatId,
+		ChatId: ChatId(chatId),
 	}
 
 	jsonData, err := json.Marshal(r)
@@ -90,28 +135,37 @@ func (c GroupsCategory) GetGroupData(chatId string) (*APIResponse, error) {
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "getGroupData", jsonData)
+	return c.GreenAPI.Request("POST", "getGroupData", jsonData, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ GroupParticipant
 
 type RequestModifyGroupParticipant struct {
-	ChatId            string `json:"chatId"`
-	ParticipantChatId string `json:"participantChatId"`
+	ChatId            ChatId `json:"chatId"`
+	ParticipantChatId ChatId `json:"participantChatId"`
+}
+
+type ResponseAddGroupParticipant struct {
+	AddParticipant bool `json:"addParticipant"`
 }
 
 // Adding a participant to a group chat.
 //
 // https://green-api.com/v3/docs/api/groups/AddGroupParticipant/
 func (c GroupsCategory) AddGroupParticipant(chatId, participantChatId string) (*APIResponse, error) {
+	return c.AddGroupParticipantCtx(context.Background(), chatId, participantChatId)
+}
+
+// AddGroupParticipantCtx is like AddGroupParticipant but uses ctx to cancel or time out the request.
+func (c GroupsCategory) AddGroupParticipantCtx(ctx context.Context, chatId, participantChatId string) (*APIResponse, error) {
 	err := ValidateChatId(chatId, participantChatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestModifyGroupParticipant{
-		ChatId:            chatId,
-		ParticipantChatId: participantChatId,
+		ChatId:            ChatId(chatId),
+		ParticipantChatId: ChatId(participantChatId),
 	}
 
 	jsonData, err := json.Marshal(r)
@@ -119,21 +173,30 @@ func (c GroupsCategory) AddGroupParticipant(chatId, participantChatId string) (*
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "addGroupParticipant", jsonData)
+	return c.GreenAPI.Request("POST", "addGroupParticipant", jsonData, WithContext(ctx))
+}
+
+type ResponseRemoveGroupParticipant struct {
+	RemoveParticipant bool `json:"removeParticipant"`
 }
 
 // Removing a participant from a group chat.
 //
 // https://green-api.com/v3/docs/api/groups/RemoveGroupParticipant/
 func (c GroupsCategory) RemoveGroupParticipant(chatId, participantChatId string) (*APIResponse, error) {
+	return c.RemoveGroupParticipantCtx(context.Background(), chatId, participantChatId)
+}
+
+// RemoveGroupParticipantCtx is like RemoveGroupParticipant but uses ctx to cancel or time out the request.
+func (c GroupsCategory) RemoveGroupParticipantCtx(ctx context.Context, chatId, participantChatId string) (*APIResponse, error) {
 	err := ValidateChatId(chatId, participantChatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestModifyGroupParticipant{
-		ChatId:            chatId,
-		ParticipantChatId: participantChatId,
+		ChatId:            ChatId(chatId),
+		ParticipantChatId: ChatId(participantChatId),
 	}
 
 	jsonData, err := json.Marshal(r)
@@ -141,21 +204,30 @@ func (c GroupsCategory) RemoveGroupParticipant(chatId, participantChatId string)
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "removeGroupParticipant", jsonData)
+	return c.GreenAPI.Request("POST", "removeGroupParticipant", jsonData, WithContext(ctx))
+}
+
+type ResponseSetGroupAdmin struct {
+	SetGroupAdmin bool `json:"setGroupAdmin"`
 }
 
 // Setting a group chat participant as an administrator.
 //
 // https://green-api.com/v3/docs/api/groups/SetGroupAdmin/
 func (c GroupsCategory) SetGroupAdmin(chatId, participantChatId string) (*APIResponse, error) {
+	return c.SetGroupAdminCtx(context.Background(), chatId, participantChatId)
+}
+
+// SetGroupAdminCtx is like SetGroupAdmin but uses ctx to cancel or time out the request.
+func (c GroupsCategory) SetGroupAdminCtx(ctx context.Context, chatId, participantChatId string) (*APIResponse, error) {
 	err := ValidateChatId(chatId, participantChatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestModifyGroupParticipant{
-		ChatId:            chatId,
-		ParticipantChatId: participantChatId,
+		ChatId:            ChatId(chatId),
+		ParticipantChatId: ChatId(participantChatId),
 	}
 
 	jsonData, err := json.Marshal(r)
@@ -163,21 +235,30 @@ func (c GroupsCategory) SetGroupAdmin(chatId, participantChatId string) (*APIRes
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "setGroupAdmin", jsonData)
+	return c.GreenAPI.Request("POST", "setGroupAdmin", jsonData, WithContext(ctx))
+}
+
+type ResponseRemoveAdmin struct {
+	RemoveAdmin bool `json:"removeAdmin"`
 }
 
 // Removing a participant from the group chat administration rights.
 //
 // https://green-api.com/v3/docs/api/groups/RemoveAdmin/
 func (c GroupsCategory) RemoveAdmin(chatId, participantChatId string) (*APIResponse, error) {
+	return c.RemoveAdminCtx(context.Background(), chatId, participantChatId)
+}
+
+// RemoveAdminCtx is like RemoveAdmin but uses ctx to cancel or time out the request.
+func (c GroupsCategory) RemoveAdminCtx(ctx context.Context, chatId, participantChatId string) (*APIResponse, error) {
 	err := ValidateChatId(chatId, participantChatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestModifyGroupParticipant{
-		ChatId:            chatId,
-		ParticipantChatId: participantChatId,
+		ChatId:            ChatId(chatId),
+		ParticipantChatId: ChatId(participantChatId),
 	}
 
 	jsonData, err := json.Marshal(r)
@@ -185,55 +266,85 @@ func (c GroupsCategory) RemoveAdmin(chatId, participantChatId string) (*APIRespo
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "removeAdmin", jsonData)
+	return c.GreenAPI.Request("POST", "removeAdmin", jsonData, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ SetGroupPicture
 
 type RequestSetGroupPicture struct {
-	File   string `json:"file"`
-	ChatId string `json:"chatId"`
+	File   File   `json:"-" form:"file"`
+	ChatId ChatId `json:"chatId" form:"chatId"`
+	// Deprecated: Use File. The path of the file for a request encoded with
+	// json.Marshal and sent with Request and WithFormData.
+	FilePath string `json:"file,omitempty" form:"-"`
+}
+
+type ResponseSetGroupPicture struct {
+	SetGroupPicture bool   `json:"setGroupPicture"`
+	UrlAvatar       string `json:"urlAvatar"`
+	Reason          string `json:"reason,omitempty"`
 }
 
 // Setting a group picture.
 //
 // https://green-api.com/v3/docs/api/groups/SetGroupPicture/
 func (c GroupsCategory) SetGroupPicture(filepath, chatId string) (*APIResponse, error) {
+	return c.SetGroupPictureCtx(context.Background(), filepath, chatId)
+}
+
+// SetGroupPictureCtx is like SetGroupPicture but uses ctx to cancel or time out the request.
+func (c GroupsCategory) SetGroupPictureCtx(ctx context.Context, filepath, chatId string) (*APIResponse, error) {
+	return c.SetGroupPictureFromCtx(ctx, FileFromPath(filepath), chatId)
+}
+
+// Setting a group picture from any source, see File.
+//
+// https://green-api.com/v3/docs/api/groups/SetGroupPicture/
+func (c GroupsCategory) SetGroupPictureFrom(file File, chatId string) (*APIResponse, error) {
+	return c.SetGroupPictureFromCtx(context.Background(), file, chatId)
+}
+
+// SetGroupPictureFromCtx is like SetGroupPictureFrom but uses ctx to cancel or time out the request.
+func (c GroupsCategory) SetGroupPictureFromCtx(ctx context.Context, file File, chatId string) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestSetGroupPicture{
-		File:   filepath,
-		ChatId: chatId,
-	}
-
-	jsonData, err := json.Marshal(r)
-	if err != nil {
-		return nil, err
+		File:   file,
+		ChatId: ChatId(chatId),
 	}
 
-	return c.GreenAPI.Request("POST", "setGroupPicture", jsonData, WithFormData(true))
+	return uploadMultipart(ctx, c.GreenAPI, "setGroupPicture", r)
 }
 
 // ------------------------------------------------------------------ LeaveGroup
 
 type RequestLeaveGroup struct {
-	ChatId string `json:"chatId"`
+	ChatId ChatId `json:"chatId"`
+}
+
+type ResponseLeaveGroup struct {
+	LeaveGroup bool `json:"leaveGroup"`
 }
 
 // Leaving a group chat.
 //
 // https://green-api.com/v3/docs/api/groups/LeaveGroup/
 func (c GroupsCategory) LeaveGroup(chatId string) (*APIResponse, error) {
+	return c.LeaveGroupCtx(context.Background(), chatId)
+}
+
+// LeaveGroupCtx is like LeaveGroup but uses ctx to cancel or time out the request.
+func (c GroupsCategory) LeaveGroupCtx(ctx context.Context, chatId string) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestLeaveGroup{
-		ChatId: chatId,
+		ChatId: ChatId(chatId),
 	}
 
 	jsonData, err := json.Marshal(r)
@@ -241,5 +352,5 @@ func (c GroupsCategory) LeaveGroup(chatId string) (*APIResponse, error) {
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "leaveGroup", jsonData)
+	return c.GreenAPI.Request("POST", "leaveGroup", jsonData, WithContext(ctx))
 }
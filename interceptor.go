@@ -0,0 +1,85 @@
+package greenapi
+
+import (
+	"context"
+	"net/http"
+)
+
+// Call is a call of an API method passed through the interceptors. An
+// interceptor may change it before calling next, e.g. add a header or
+// replace the body in a test.
+type Call struct {
+	// The context of the request, see WithContext.
+	Context    context.Context
+	HTTPMethod string
+	// API method, e.g. "sendMessage".
+	APIMethod string
+	// Body of the request, nil for a file streamed with WithBodyStream.
+	Body []byte
+	// Whether the body is sent as multipart/form-data, see WithFormData.
+	FormData bool
+	// Whether the request is sent to MediaURL, see WithMediaHost.
+	MediaHost bool
+	// Headers added to the HTTP request.
+	Header http.Header
+}
+
+// Invoker sends the call to the next interceptor, or to the API after the last one.
+type Invoker func(call *Call) (*APIResponse, error)
+
+// Interceptor runs around every request of GreenAPI or GreenAPIPartner. It
+// calls next to send the call and returns its response and error, which may
+// be an *APIError with WithAPIErrors, or returns without calling next to stop
+// the request, e.g. while a circuit breaker is open:
+//
+//	logging := func(call *greenapi.Call, next greenapi.Invoker) (*greenapi.APIResponse, error) {
+//		start := time.Now()
+//		resp, err := next(call)
+//		log.Printf("%s took %v, error: %v", call.APIMethod, time.Since(start), err)
+//		return resp, err
+//	}
+//
+// Interceptors run once per call, outside the rate limits and retries.
+type Interceptor func(call *Call, next Invoker) (*APIResponse, error)
+
+// Run the interceptors around every request, in order: the first one sees
+// the call first and the response last. The option may be repeated.
+func WithInterceptors(interceptors ...Interceptor) ClientOption {
+	return func(c *clientConfig) error {
+		c.Interceptors = append(c.Interceptors, interceptors...)
+		return nil
+	}
+}
+
+func newCall(r *requestType, HTTPMethod, APIMethod string, requestBody []byte) *Call {
+	return &Call{
+		Context:    r.Context,
+		HTTPMethod: HTTPMethod,
+		APIMethod:  APIMethod,
+		Body:       requestBody,
+		FormData:   r.FormData,
+		MediaHost:  r.MediaHost,
+		Header:     make(http.Header),
+	}
+}
+
+// apply copies the options changed by the interceptors to r.
+func (call *Call) apply(r *requestType) {
+	if call.Context != nil {
+		r.Context = call.Context
+	}
+	r.FormData = call.FormData
+	r.MediaHost = call.MediaHost
+	r.Header = call.Header
+}
+
+// intercept passes the call through the interceptors of the client to invoke.
+func (c *apiClient) intercept(call *Call, invoke Invoker) (*APIResponse, error) {
+	for n := len(c.interceptors) - 1; n >= 0; n-- {
+		interceptor, next := c.interceptors[n], invoke
+		invoke = func(call *Call) (*APIResponse, error) {
+			return interceptor(call, next)
+		}
+	}
+	return invoke(call)
+}
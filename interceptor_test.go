@@ -0,0 +1,76 @@
+package greenapi_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/greenapitest"
+)
+
+func TestInterceptorsOrder(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+
+	var order []string
+	record := func(name string) greenapi.Interceptor {
+		return func(call *greenapi.Call, next greenapi.Invoker) (*greenapi.APIResponse, error) {
+			order = append(order, name+" "+call.APIMethod)
+			call.Header.Add("X-Interceptors", name)
+			resp, err := next(call)
+			order = append(order, name+" response")
+			return resp, err
+		}
+	}
+
+	GreenAPI := newGreenAPI(t, server, instance,
+		greenapi.WithInterceptors(record("first"), record("second")),
+		greenapi.WithInterceptors(record("third")),
+	)
+	if _, err := GreenAPI.Account().GetSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"first getSettings", "second getSettings", "third getSettings", "third response", "second response", "first response"}
+	if !slices.Equal(order, want) {
+		t.Errorf("order %q, want %q", order, want)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("%d requests sent, want 1", len(requests))
+	}
+	if got := requests[0].Header.Values("X-Interceptors"); !slices.Equal(got, []string{"first", "second", "third"}) {
+		t.Errorf("headers %q, want the header of every interceptor in order", got)
+	}
+}
+
+func TestInterceptorStopsRequest(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+
+	errOpen := errors.New("circuit breaker is open")
+	var reached bool
+	GreenAPI := newGreenAPI(t, server, instance,
+		greenapi.WithInterceptors(func(call *greenapi.Call, next greenapi.Invoker) (*greenapi.APIResponse, error) {
+			return nil, errOpen
+		}),
+		greenapi.WithInterceptors(func(call *greenapi.Call, next greenapi.Invoker) (*greenapi.APIResponse, error) {
+			reached = true
+			return next(call)
+		}),
+	)
+
+	if _, err := GreenAPI.Sending().SendMessage("10000000", "Hello"); !errors.Is(err, errOpen) {
+		t.Errorf("got %v, want the error of the interceptor", err)
+	}
+	if reached {
+		t.Error("the next interceptor was called")
+	}
+	if got := len(server.Requests()); got != 0 {
+		t.Errorf("%d requests sent, want 0", got)
+	}
+}
@@ -1,6 +1,7 @@
 package greenapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -12,7 +13,7 @@ type JournalsCategory struct {
 // ------------------------------------------------------------------ GetChatHistory
 
 type RequestGetChatHistory struct {
-	ChatId string `json:"chatId"`
+	ChatId ChatId `json:"chatId"`
 	Count  int    `json:"count,omitempty"`
 }
 
@@ -26,6 +27,42 @@ func OptionalCount(count int) GetChatHistoryOption {
 	}
 }
 
+// JournalMessage is a message returned by the journal methods.
+// Fields that do not apply to the message type are left empty.
+type JournalMessage struct {
+	Type              string `json:"type"`
+	IdMessage         string `json:"idMessage"`
+	Timestamp         int64  `json:"timestamp"`
+	TypeMessage       string `json:"typeMessage"`
+	ChatId            string `json:"chatId"`
+	SenderId          string `json:"senderId,omitempty"`
+	SenderName        string `json:"senderName,omitempty"`
+	SenderContactName string `json:"senderContactName,omitempty"`
+	TextMessage       string `json:"textMessage,omitempty"`
+	DownloadUrl       string `json:"downloadUrl,omitempty"`
+	Caption           string `json:"caption,omitempty"`
+	FileName          string `json:"fileName,omitempty"`
+	MimeType          string `json:"mimeType,omitempty"`
+	StatusMessage     string `json:"statusMessage,omitempty"`
+	SendByApi         bool   `json:"sendByApi,omitempty"`
+	IsForwarded       bool   `json:"isForwarded,omitempty"`
+	ForwardingScore   int    `json:"forwardingScore,omitempty"`
+
+	ExtendedTextMessage *JournalExtendedText `json:"extendedTextMessage,omitempty"`
+	QuotedMessage       json.RawMessage      `json:"quotedMessage,omitempty"`
+	Location            json.RawMessage      `json:"location,omitempty"`
+	Contact             json.RawMessage      `json:"contact,omitempty"`
+}
+
+type JournalExtendedText struct {
+	Text        string `json:"text"`
+	Description string `json:"description,omitempty"`
+	Title       string `json:"title,omitempty"`
+	PreviewType string `json:"previewType,omitempty"`
+}
+
+type ResponseGetChatHistory []JournalMessage
+
 // Getting a chat messages history.
 //
 // https://green-api.com/v3/docs/api/journals/GetChatHistory/
@@ -34,13 +71,18 @@ func OptionalCount(count int) GetChatHistoryOption {
 //
 //	OptionalCount(count int) <- The number of messages to get. The default is 100
 func (c JournalsCategory) GetChatHistory(chatId string, options ...GetChatHistoryOption) (*APIResponse, error) {
+	return c.GetChatHistoryCtx(context.Background(), chatId, options...)
+}
+
+// GetChatHistoryCtx is like GetChatHistory but uses ctx to cancel or time out the request.
+func (c JournalsCategory) GetChatHistoryCtx(ctx context.Context, chatId string, options ...GetChatHistoryOption) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestGetChatHistory{
-		ChatId: chatId,
+		ChatId: ChatId(chatId),
 	}
 
 	for _, o := range options {
@@ -55,27 +97,34 @@ func (c JournalsCategory) GetChatHistory(chatId string, options ...GetChatHistor
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "getChatHistory", jsonData)
+	return c.GreenAPI.Request("POST", "getChatHistory", jsonData, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ GetMessage
 
 type RequestGetMessage struct {
-	ChatId    string `json:"chatId"`
+	ChatId    ChatId `json:"chatId"`
 	IdMessage string `json:"idMessage"`
 }
 
+type ResponseGetMessage = JournalMessage
+
 // Getting a message information.
 //
 // https://green-api.com/v3/docs/api/journals/GetMessage/
 func (c JournalsCategory) GetMessage(chatId, idMessage string) (*APIResponse, error) {
+	return c.GetMessageCtx(context.Background(), chatId, idMessage)
+}
+
+// GetMessageCtx is like GetMessage but uses ctx to cancel or time out the request.
+func (c JournalsCategory) GetMessageCtx(ctx context.Context, chatId, idMessage string) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestGetMessage{
-		ChatId:    chatId,
+		ChatId:    ChatId(chatId),
 		IdMessage: idMessage,
 	}
 
@@ -84,7 +133,7 @@ func (c JournalsCategory) GetMessage(chatId, idMessage string) (*APIResponse, er
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "getMessage", jsonData)
+	return c.GreenAPI.Request("POST", "getMessage", jsonData, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ LastIncomingMessages + LastOutgoingMessages
@@ -103,6 +152,8 @@ func OptionalMinutes(minutes int) LastMessagesOption {
 	}
 }
 
+type ResponseLastMessages []JournalMessage
+
 // Getting the last incoming messages of the account.
 //
 // https://green-api.com/v3/docs/api/journals/LastIncomingMessages/
@@ -111,6 +162,11 @@ func OptionalMinutes(minutes int) LastMessagesOption {
 //
 //	OptionalMinutes(minutes int) <- Time in minutes for which the messages should be displayed (default is 1440 minutes)
 func (c JournalsCategory) LastIncomingMessages(options ...LastMessagesOption) (*APIResponse, error) {
+	return c.LastIncomingMessagesCtx(context.Background(), options...)
+}
+
+// LastIncomingMessagesCtx is like LastIncomingMessages but uses ctx to cancel or time out the request.
+func (c JournalsCategory) LastIncomingMessagesCtx(ctx context.Context, options ...LastMessagesOption) (*APIResponse, error) {
 	r := &RequestLastMessages{}
 
 	for _, o := range options {
@@ -130,7 +186,7 @@ func (c JournalsCategory) LastIncomingMessages(options ...LastMessagesOption) (*
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("GET", "lastIncomingMessages", jsonData, WithGetParams(addUrl))
+	return c.GreenAPI.Request("GET", "lastIncomingMessages", jsonData, WithGetParams(addUrl), WithContext(ctx))
 }
 
 // Getting the last outgoung messages of the account.
@@ -139,6 +195,11 @@ func (c JournalsCategory) LastIncomingMessages(options ...LastMessagesOption) (*
 //
 //	OptionalMinutes(minutes int) <- Time in minutes for which the messages should be displayed (default is 1440 minutes)
 func (c JournalsCategory) LastOutgoingMessages(options ...LastMessagesOption) (*APIResponse, error) {
+	return c.LastOutgoingMessagesCtx(context.Background(), options...)
+}
+
+// LastOutgoingMessagesCtx is like LastOutgoingMessages but uses ctx to cancel or time out the request.
+func (c JournalsCategory) LastOutgoingMessagesCtx(ctx context.Context, options ...LastMessagesOption) (*APIResponse, error) {
 	r := &RequestLastMessages{}
 
 	for _, o := range options {
@@ -158,5 +219,5 @@ func (c JournalsCategory) LastOutgoingMessages(options ...LastMessagesOption) (*
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("GET", "lastOutgoingMessages", jsonData, WithGetParams(addUrl))
+	return c.GreenAPI.Request("GET", "lastOutgoingMessages", jsonData, WithGetParams(addUrl), WithContext(ctx))
 }
@@ -0,0 +1,290 @@
+package greenapi
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// Request structs of upload methods are encoded as multipart/form-data by their
+// form tags:
+//
+//	Field string `form:"name"`           <- sent as a form field, also when empty
+//	Field int    `form:"name,omitempty"` <- not sent when zero
+//	Field File   `form:"name"`           <- sent as a file part
+//	Field bool   `form:"-"`              <- not sent, as are fields without a form tag
+//
+// Strings, booleans, integers, floats, encoding.TextMarshaler, pointers to them
+// and slices of them (sent as repeated fields) are supported. Other types cause
+// an error instead of being sent.
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// The number of first bytes used to detect the MIME type of a file.
+const sniffLen = 3072
+
+var (
+	fileType          = reflect.TypeOf(File{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type formField struct {
+	Name  string
+	Value string
+}
+
+type formFile struct {
+	Field string
+	File  File
+}
+
+// filePart is an opened file of a multipart body.
+type filePart struct {
+	Field  string
+	Name   string
+	Reader io.Reader
+	Size   int64
+}
+
+// encodeForm returns the form fields and files of v, a struct or a pointer to a struct.
+func encodeForm(v any) ([]formField, []formFile, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, nil, errors.New("form: nil request")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("form: request must be a struct, got %s", rv.Type())
+	}
+
+	var fields []formField
+	var files []formFile
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag, ok := sf.Tag.Lookup("form")
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			return nil, nil, fmt.Errorf("form: field %s has an empty name", sf.Name)
+		}
+		omitempty := false
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "":
+			case "omitempty":
+				omitempty = true
+			default:
+				return nil, nil, fmt.Errorf("form: field %s has unknown option %q", sf.Name, opt)
+			}
+		}
+
+		fv := rv.Field(i)
+		if sf.Type == fileType {
+			file := fv.Interface().(File)
+			if file.open == nil {
+				if omitempty {
+					continue
+				}
+				return nil, nil, fmt.Errorf("form: file %s has no content", name)
+			}
+			files = append(files, formFile{Field: name, File: file})
+			continue
+		}
+
+		if omitempty && fv.IsZero() {
+			continue
+		}
+
+		values, err := formValues(fv)
+		if err != nil {
+			return nil, nil, fmt.Errorf("form: field %s: %w", name, err)
+		}
+		for _, value := range values {
+			fields = append(fields, formField{Name: name, Value: value})
+		}
+	}
+
+	return fields, files, nil
+}
+
+// formValues returns the form values of v: none for a nil pointer,
+// one for a scalar and one per element for a slice or an array.
+func formValues(v reflect.Value) ([]string, error) {
+	if v.Kind() == reflect.Pointer && !v.Type().Implements(textMarshalerType) {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && !v.Type().Implements(textMarshalerType) {
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil, fmt.Errorf("unsupported type %s", v.Type())
+		}
+		values := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			value, err := formValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+
+	value, err := formValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return []string{value}, nil
+}
+
+func formValue(v reflect.Value) (string, error) {
+	if v.Type().Implements(textMarshalerType) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return "", nil
+		}
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// DetectMimeType detects the MIME type of the content of r from its first bytes,
+// as it is done for uploaded and downloaded files, and returns a reader of the
+// whole content.
+func DetectMimeType(r io.Reader) (string, io.Reader, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	head = head[:n]
+
+	return mimetype.Detect(head).String(), io.MultiReader(bytes.NewReader(head), r), nil
+}
+
+// multipartStream returns a multipart/form-data body of the fields followed by
+// the file parts, that reads the files while the body is sent. length is -1 when
+// the size of any file is unknown (negative).
+func multipartStream(fields []formField, files []filePart) (contentType string, body io.Reader, length int64, err error) {
+	buffer := &bytes.Buffer{}
+	writer := multipart.NewWriter(buffer)
+
+	for _, f := range fields {
+		err := writer.WriteField(f.Name, f.Value)
+		if err != nil {
+			return "", nil, 0, err
+		}
+	}
+
+	// The body alternates the headers written to the buffer and the file contents.
+	var readers []io.Reader
+	var contentLength int64
+	offset := 0
+
+	for _, f := range files {
+		fileMimetype, file, err := DetectMimeType(f.Reader)
+		if err != nil {
+			return "", nil, 0, err
+		}
+
+		//this is modified code of writer.CreateFormFile function
+		//the original function does not allow to set Content-Type of a particular field of Form-Data other than application/octet
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(f.Field), quoteEscaper.Replace(f.Name)))
+		h.Set("Content-Type", fileMimetype)
+
+		_, err = writer.CreatePart(h)
+		if err != nil {
+			return "", nil, 0, err
+		}
+
+		readers = append(readers, bytes.NewReader(buffer.Bytes()[offset:buffer.Len()]), file)
+		offset = buffer.Len()
+
+		if f.Size < 0 || contentLength < 0 {
+			contentLength = -1
+		} else {
+			contentLength += f.Size
+		}
+	}
+
+	// Close writes the closing boundary, which goes after the last file content.
+	err = writer.Close()
+	if err != nil {
+		return "", nil, 0, err
+	}
+	readers = append(readers, bytes.NewReader(buffer.Bytes()[offset:]))
+
+	length = -1
+	if contentLength >= 0 {
+		length = int64(buffer.Len()) + contentLength
+	}
+
+	return writer.FormDataContentType(), io.MultiReader(readers...), length, nil
+}
+
+// uploadMultipart sends the request struct encoded by its form tags as a
+// multipart/form-data request, reading the files while the request is sent.
+func uploadMultipart(ctx context.Context, api GreenAPIInterface, APIMethod string, request any, options ...requestOptions) (*APIResponse, error) {
+	fields, files, err := encodeForm(request)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]filePart, 0, len(files))
+	for _, f := range files {
+		r, size, err := f.File.reader()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		parts = append(parts, filePart{Field: f.Field, Name: f.File.Name, Reader: r, Size: size})
+	}
+
+	contentType, body, length, err := multipartStream(fields, parts)
+	if err != nil {
+		return nil, err
+	}
+
+	options = append([]requestOptions{
+		WithSetMimetype(mtype{Mimetype: contentType}),
+		WithBodyStream(body, length),
+		WithContext(ctx),
+	}, options...)
+
+	return api.Request("POST", APIMethod, nil, options...)
+}
@@ -0,0 +1,214 @@
+package greenapi
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEncodeForm(t *testing.T) {
+	count := 3
+	for _, tt := range []struct {
+		name       string
+		request    any
+		wantFields []formField
+		wantFiles  []string
+		wantErr    bool
+	}{
+		{
+			name: "fields and file",
+			request: &RequestSendFileByUpload{
+				ChatId:   "+7 (999) 123-45-67@c.us",
+				File:     FileFromBytes("photo.png", []byte("png")),
+				FileName: "photo.png",
+			},
+			wantFields: []formField{{"chatId", "79991234567@c.us"}, {"fileName", "photo.png"}},
+			wantFiles:  []string{"file"},
+		},
+		{
+			name: "omitempty",
+			request: struct {
+				Empty   string `form:"empty"`
+				Omitted string `form:"omitted,omitempty"`
+				File    File   `form:"file,omitempty"`
+			}{},
+			wantFields: []formField{{"empty", ""}},
+		},
+		{
+			name: "scalars, pointers and slices",
+			request: struct {
+				Flag     bool     `form:"flag"`
+				Count    *int     `form:"count"`
+				Nil      *int     `form:"nil"`
+				Ratio    float64  `form:"ratio"`
+				Tags     []string `form:"tag"`
+				Skipped  string   `form:"-"`
+				Untagged string
+			}{Flag: true, Count: &count, Ratio: 0.5, Tags: []string{"a", "b"}, Skipped: "x", Untagged: "y"},
+			wantFields: []formField{{"flag", "true"}, {"count", "3"}, {"ratio", "0.5"}, {"tag", "a"}, {"tag", "b"}},
+		},
+		{
+			name:    "file without content",
+			request: RequestSendFileByUpload{ChatId: "10000000"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid chat id",
+			request: RequestSendFileByUpload{ChatId: "chat", File: FileFromBytes("a.txt", nil)},
+			wantErr: true,
+		},
+		{
+			name: "unsupported type",
+			request: struct {
+				Data []byte `form:"data"`
+			}{Data: []byte("data")},
+			wantErr: true,
+		},
+		{
+			name: "unknown option",
+			request: struct {
+				Name string `form:"name,required"`
+			}{},
+			wantErr: true,
+		},
+		{
+			name:    "not a struct",
+			request: "request",
+			wantErr: true,
+		},
+		{
+			name:    "nil",
+			request: (*RequestSendFileByUpload)(nil),
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, files, err := encodeForm(tt.request)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("fields %q, want %q", fields, tt.wantFields)
+			}
+			var names []string
+			for _, f := range files {
+				names = append(names, f.Field)
+			}
+			if !slices.Equal(names, tt.wantFiles) {
+				t.Errorf("files %q, want %q", names, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestMultipartStream(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 100))
+	text := []byte("Hello, world")
+
+	for _, tt := range []struct {
+		name       string
+		size       int64
+		wantLength bool
+	}{
+		{"known size", int64(len(text)), true},
+		{"unknown size", -1, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, body, length, err := multipartStream(
+				[]formField{{"chatId", "10000000"}},
+				[]filePart{
+					{Field: "file", Name: "image.png", Reader: bytes.NewReader(png), Size: int64(len(png))},
+					{Field: "file", Name: `say "hi".txt`, Reader: bytes.NewReader(text), Size: tt.size},
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantLength && length != int64(len(data)) {
+				t.Errorf("length %d, want %d", length, len(data))
+			}
+			if !tt.wantLength && length != -1 {
+				t.Errorf("length %d, want -1", length)
+			}
+
+			_, params, err := mime.ParseMediaType(contentType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader := multipart.NewReader(bytes.NewReader(data), params["boundary"])
+
+			for _, want := range []struct {
+				field, fileName, contentType string
+				content                      []byte
+			}{
+				{"chatId", "", "", []byte("10000000")},
+				{"file", "image.png", "image/png", png},
+				{"file", `say "hi".txt`, "text/plain; charset=utf-8", text},
+			} {
+				part, err := reader.NextPart()
+				if err != nil {
+					t.Fatal(err)
+				}
+				content, err := io.ReadAll(part)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if part.FormName() != want.field || part.FileName() != want.fileName ||
+					part.Header.Get("Content-Type") != want.contentType || !bytes.Equal(content, want.content) {
+					t.Errorf("part %q %q %q %q, want %q %q %q %q",
+						part.FormName(), part.FileName(), part.Header.Get("Content-Type"), content,
+						want.field, want.fileName, want.contentType, want.content)
+				}
+			}
+			if _, err := reader.NextPart(); err != io.EOF {
+				t.Errorf("got %v after the last part, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestDetectMimeType(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 100)
+
+	for _, tt := range []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"empty", "", "text/plain"},
+		{"text", "Hello, world", "text/plain; charset=utf-8"},
+		{"png", png, "image/png"},
+		{"longer than the sniffed head", png + strings.Repeat("\x01", 2*sniffLen), "image/png"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, r, err := DetectMimeType(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("MIME type %q, want %q", got, tt.want)
+			}
+			content, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.content {
+				t.Errorf("read %d bytes, want the whole content of %d bytes", len(content), len(tt.content))
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+)
+
+const deleteTimeout = 10 * time.Second
+
+// Consumer receives notifications with the HTTP API technology: it calls
+// ReceiveNotification in a loop, dispatches every notification to the handler
+// registered for its type and deletes it from the queue once the handler succeeds.
+//
+// The API returns the same notification until it is deleted, so by default
+// notifications are handled one at a time. WithEarlyAck deletes them as soon as
+// they are queued for handling instead, so that different chats are handled in parallel.
+//
+// https://green-api.com/v3/docs/api/receiving/technology-http-api/
+type Consumer struct {
+	Mux
+
+	receiving greenapi.ReceivingCategory
+	config    *config
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewConsumer creates a consumer of the instance notifications queue.
+//
+// Add optional arguments by passing these functions:
+//
+//	WithReceiveTimeout(seconds int) <- Notification waiting timeout of ReceiveNotification in seconds, from 5 to 60 (20 by default).
+//	WithBackoff(min, max time.Duration) <- Delay after a failed request or handler (from 1 to 30 seconds by default).
+//	WithErrorHandler(f func(error)) <- Function called with every error of receiving, decoding or handling a notification.
+//	WithDedupe(store DedupeStore) <- Store of handled notifications, so that a notification received again is only deleted.
+//	WithEarlyAck(workers, maxPending int) <- Delete notifications once they are queued and handle them on a pool of workers.
+func NewConsumer(receiving greenapi.ReceivingCategory, options ...Option) (*Consumer, error) {
+	c, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Consumer{
+		receiving: receiving,
+		config:    c,
+	}, nil
+}
+
+// Delete every notification from the queue as soon as it is queued for handling
+// and handle it on a pool of workers, like Dispatcher does: notifications of
+// the same chat are handled in order, different chats in parallel. The consumer
+// stops receiving while maxPending notifications are queued or being handled.
+//
+// A notification whose handler fails is not delivered again, the error is passed
+// to the error handler. A notification delivered again because DeleteNotification
+// failed is handled twice unless WithDedupe is passed too. Consumer only.
+func WithEarlyAck(workers, maxPending int) Option {
+	return func(c *config) error {
+		if workers < 1 {
+			return fmt.Errorf("number of workers must be positive, got %v", workers)
+		}
+		if maxPending < 1 {
+			return fmt.Errorf("max pending notifications must be positive, got %v", maxPending)
+		}
+		c.AckWorkers = workers
+		c.AckMaxPending = maxPending
+		return nil
+	}
+}
+
+// Run receives and handles notifications until ctx is done. It returns nil after
+// a clean stop. A notification whose handler has finished is still deleted
+// from the queue when ctx is cancelled during the handling. With WithEarlyAck,
+// Run returns after the queued notifications are handled, their handlers get
+// a context that is not cancelled with ctx.
+func (c *Consumer) Run(ctx context.Context) error {
+	backoff := c.config.MinBackoff
+
+	var d *Dispatcher
+	if c.config.AckWorkers > 0 {
+		d = newDispatcher(HandlerFunc(func(ctx context.Context, n *Notification) error {
+			return c.config.dispatch(ctx, &c.Mux, n)
+		}), c.config.AckWorkers, c.config.AckMaxPending, c.config)
+		defer d.Close()
+	}
+
+	for ctx.Err() == nil {
+		err := c.receiveOne(ctx, d)
+		if err == nil {
+			backoff = c.config.MinBackoff
+			continue
+		}
+		if stopped(ctx, err) {
+			break
+		}
+
+		c.config.ErrorHandler(err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, c.config.MaxBackoff)
+	}
+
+	return nil
+}
+
+// stopped reports whether err is caused by the end of ctx, even if the
+// request gave up a moment before ctx.Err() is set.
+func stopped(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return true
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && errors.Is(err, context.DeadlineExceeded) && !time.Now().Before(deadline)
+}
+
+// Start runs the consumer in a new goroutine. Use Stop to stop it.
+func (c *Consumer) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done != nil {
+		return errors.New("consumer is already running")
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+
+	go func(done chan struct{}) {
+		defer close(done)
+		_ = c.Run(ctx)
+	}(c.done)
+
+	return nil
+}
+
+// Stop stops the consumer started with Start and waits until the notification
+// being processed, or with WithEarlyAck the queued ones, are handled.
+func (c *Consumer) Stop() {
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.cancel, c.done = nil, nil
+	c.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// receiveOne receives, handles and deletes a notification. With a dispatcher
+// d the notification is deleted once it is queued to d.
+func (c *Consumer) receiveOne(ctx context.Context, d *Dispatcher) error {
+	resp, err := c.receiving.ReceiveNotificationCtx(ctx, greenapi.OptionalReceiveTimeout(c.config.ReceiveTimeout))
+	if err != nil {
+		return fmt.Errorf("receiveNotification: %w", err)
+	}
+
+	var body json.RawMessage
+	if err := resp.Decode(&body); err != nil {
+		return fmt.Errorf("receiveNotification: %w", err)
+	}
+
+	n, err := DecodeNotification(body)
+	if err != nil {
+		// A notification that cannot be decoded would block the queue forever.
+		var envelope struct {
+			ReceiptId int `json:"receiptId"`
+		}
+		if json.Unmarshal(body, &envelope) != nil || envelope.ReceiptId == 0 {
+			return fmt.Errorf("decoding notification: %w", err)
+		}
+		c.config.ErrorHandler(fmt.Errorf("decoding notification %v: %w", envelope.ReceiptId, err))
+		return c.delete(ctx, envelope.ReceiptId)
+	}
+	if n == nil {
+		return nil
+	}
+
+	if d != nil {
+		if err := d.queue(ctx, n); err != nil {
+			return fmt.Errorf("queueing notification %v: %w", n.ReceiptId, err)
+		}
+		return c.delete(ctx, n.ReceiptId)
+	}
+
+	if err := c.config.dispatch(ctx, &c.Mux, n); err != nil {
+		return fmt.Errorf("handling notification %v: %w", n.ReceiptId, err)
+	}
+
+	return c.delete(ctx, n.ReceiptId)
+}
+
+func (c *Consumer) delete(ctx context.Context, receiptId int) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deleteTimeout)
+	defer cancel()
+
+	resp, err := c.receiving.DeleteNotificationCtx(ctx, receiptId)
+	if err == nil {
+		err = resp.Decode(new(greenapi.ResponseDeleteNotification))
+	}
+	if err != nil {
+		return fmt.Errorf("deleteNotification %v: %w", receiptId, err)
+	}
+	return nil
+}
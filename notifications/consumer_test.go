@@ -0,0 +1,197 @@
+package notifications_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/green-api/max-api-client-golang/greenapitest"
+	"github.com/green-api/max-api-client-golang/notifications"
+)
+
+func TestConsumerDeletesAfterSuccess(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		// Texts whose first handling fails.
+		failing []string
+		want    []string
+	}{
+		{"handled", nil, []string{"first", "second"}},
+		{"handled again after a failure", []string{"first"}, []string{"first", "first", "second"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greenapitest.NewServer(greenapitest.WithMaxReceiveWait(50 * time.Millisecond))
+			defer server.Close()
+
+			instance := server.NewInstance()
+			instance.ReceiveText("10000000@c.us", "", "first")
+			instance.ReceiveText("10000000@c.us", "", "second")
+
+			consumer, err := notifications.NewConsumer(instance.GreenAPI().Receiving(),
+				notifications.WithBackoff(time.Millisecond, time.Millisecond),
+				notifications.WithErrorHandler(func(error) {}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var mu sync.Mutex
+			var handled []string
+			failing := slices.Clone(tt.failing)
+			done := make(chan struct{})
+			consumer.HandleFunc("incomingMessageReceived", func(ctx context.Context, n *notifications.Notification) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				text := n.Body.(notifications.MessageNotification).Message().MessageData.Text()
+				handled = append(handled, text)
+				if i := slices.Index(failing, text); i >= 0 {
+					failing = slices.Delete(failing, i, i+1)
+					return errors.New("handler failed")
+				}
+				if len(handled) == len(tt.want) {
+					close(done)
+				}
+				return nil
+			})
+
+			if err := consumer.Start(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the notifications were not handled")
+			}
+			consumer.Stop()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !slices.Equal(handled, tt.want) {
+				t.Errorf("handled %q, want %q", handled, tt.want)
+			}
+			if got := instance.Notifications(); len(got) != 0 {
+				t.Errorf("%d notifications left in the queue, want 0", len(got))
+			}
+		})
+	}
+}
+
+func TestConsumerEarlyAck(t *testing.T) {
+	const handleTime = 100 * time.Millisecond
+	chats := []string{"10000000@c.us", "20000000@c.us", "30000000@c.us", "40000000@c.us"}
+
+	server := greenapitest.NewServer(greenapitest.WithMaxReceiveWait(50 * time.Millisecond))
+	defer server.Close()
+
+	instance := server.NewInstance()
+	for _, text := range []string{"first", "second"} {
+		for _, chatId := range chats {
+			instance.ReceiveText(chatId, "", text)
+		}
+	}
+
+	consumer, err := notifications.NewConsumer(instance.GreenAPI().Receiving(), notifications.WithEarlyAck(8, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	handled := make(map[string][]string)
+	running, maxRunning, count := 0, 0, 0
+	done := make(chan struct{})
+	consumer.HandleFunc("incomingMessageReceived", func(ctx context.Context, n *notifications.Notification) error {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		time.Sleep(handleTime)
+
+		mu.Lock()
+		defer mu.Unlock()
+		running--
+		chatId := notifications.ChatId(n.Body)
+		handled[chatId] = append(handled[chatId], n.Body.(notifications.MessageNotification).Message().MessageData.Text())
+		if count++; count == 2*len(chats) {
+			close(done)
+		}
+		return nil
+	})
+
+	start := time.Now()
+	if err := consumer.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the notifications were not handled")
+	}
+	elapsed := time.Since(start)
+	consumer.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, chatId := range chats {
+		if want := []string{"first", "second"}; !slices.Equal(handled[chatId], want) {
+			t.Errorf("chat %s handled %q, want %q", chatId, handled[chatId], want)
+		}
+	}
+	if maxRunning < 2 {
+		t.Errorf("at most %d notifications were handled at a time, want different chats in parallel", maxRunning)
+	}
+	if sequential := time.Duration(2*len(chats)) * handleTime; elapsed >= sequential*3/4 {
+		t.Errorf("handling took %v, want less than %v of handling one at a time", elapsed, sequential)
+	}
+	if got := instance.Notifications(); len(got) != 0 {
+		t.Errorf("%d notifications left in the queue, want 0", len(got))
+	}
+}
+
+func TestConsumerEarlyAckReportsHandlerErrors(t *testing.T) {
+	server := greenapitest.NewServer(greenapitest.WithMaxReceiveWait(50 * time.Millisecond))
+	defer server.Close()
+
+	instance := server.NewInstance()
+	instance.ReceiveText("10000000@c.us", "", "first")
+
+	errs := make(chan error, 1)
+	consumer, err := notifications.NewConsumer(instance.GreenAPI().Receiving(),
+		notifications.WithEarlyAck(1, 1),
+		notifications.WithErrorHandler(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlerErr := errors.New("handler failed")
+	consumer.HandleFunc("incomingMessageReceived", func(ctx context.Context, n *notifications.Notification) error {
+		return handlerErr
+	})
+
+	if err := consumer.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, handlerErr) {
+			t.Errorf("got %v, want the error of the handler", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the error of the handler was not reported")
+	}
+	consumer.Stop()
+
+	// The notification was deleted when it was queued, so it is not handled again.
+	if got := instance.Notifications(); len(got) != 0 {
+		t.Errorf("%d notifications left in the queue, want 0", len(got))
+	}
+}
@@ -0,0 +1,155 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+var errMissingType = errors.New("notification has no typeWebhook field")
+
+// Decode decodes a notification payload, as posted to webhookUrl or found in the body
+// of a ReceiveNotification response, into its concrete type. Notifications of unknown
+// types are returned as *UnknownWebhook, so that new API features do not break decoding.
+func Decode(data []byte) (Webhook, error) {
+	var header struct {
+		TypeWebhook string `json:"typeWebhook"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	if header.TypeWebhook == "" {
+		return nil, errMissingType
+	}
+
+	var w interface {
+		Webhook
+		header() *Header
+	}
+	switch header.TypeWebhook {
+	case TypeIncomingMessageReceived:
+		w = &IncomingMessageReceived{}
+	case TypeOutgoingMessageReceived:
+		w = &OutgoingMessageReceived{}
+	case TypeOutgoingAPIMessageReceived:
+		w = &OutgoingAPIMessageReceived{}
+	case TypeOutgoingMessageStatus:
+		w = &OutgoingMessageStatus{}
+	case TypeStateInstanceChanged:
+		w = &StateInstanceChanged{}
+	case TypeIncomingCall:
+		w = &IncomingCall{}
+	default:
+		w = &UnknownWebhook{}
+	}
+
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, err
+	}
+
+	h := w.header()
+	h.raw = append(json.RawMessage(nil), data...)
+	h.Extra = unknownFields(data, w)
+
+	if m, ok := w.(MessageNotification); ok {
+		var fields struct {
+			MessageData json.RawMessage `json:"messageData"`
+		}
+		if err := json.Unmarshal(data, &fields); err == nil && fields.MessageData != nil {
+			m.Message().MessageData.Extra = unknownFields(fields.MessageData, &m.Message().MessageData)
+		}
+	}
+
+	return w, nil
+}
+
+// DecodeNotification decodes the body of a ReceiveNotification response.
+// It returns nil and no error if the notifications queue is empty.
+func DecodeNotification(data []byte) (*Notification, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	var envelope struct {
+		ReceiptId int             `json:"receiptId"`
+		Body      json.RawMessage `json:"body"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+
+	body, err := Decode(envelope.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Notification{
+		ReceiptId: envelope.ReceiptId,
+		Body:      body,
+	}, nil
+}
+
+// ChatId returns the chat a notification belongs to,
+// or an empty string for notifications not related to a chat.
+func ChatId(w Webhook) string {
+	switch w := w.(type) {
+	case MessageNotification:
+		return w.Message().SenderData.ChatId
+	case *OutgoingMessageStatus:
+		return w.ChatId
+	case *IncomingCall:
+		return w.From
+	}
+	return ""
+}
+
+func (h *Header) header() *Header {
+	return h
+}
+
+// unknownFields returns the top-level fields of data that do not map to a json tag of v.
+func unknownFields(data []byte, v any) map[string]json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+
+	for name := range knownFields(reflect.TypeOf(v)) {
+		delete(fields, name)
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+func knownFields(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	known := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" {
+			for name := range knownFields(f.Type) {
+				known[name] = true
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		known[name] = true
+	}
+	return known
+}
@@ -0,0 +1,130 @@
+package notifications_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/green-api/max-api-client-golang/notifications"
+)
+
+func TestDecode(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		payload   string
+		wantType  reflect.Type
+		wantExtra []string
+		// Unknown fields of messageData, for message notifications.
+		wantMessageExtra []string
+		wantErr          bool
+	}{
+		{
+			name: "known type",
+			payload: `{"typeWebhook":"incomingMessageReceived","timestamp":1700000000,"idMessage":"BAE5000000000001",` +
+				`"instanceData":{"idInstance":1101000001,"wid":"79990000000@c.us","typeInstance":"max"},` +
+				`"senderData":{"chatId":"10000000@c.us","sender":"10000000@c.us"},` +
+				`"messageData":{"typeMessage":"textMessage","textMessageData":{"textMessage":"Hello"}}}`,
+			wantType: reflect.TypeFor[*notifications.IncomingMessageReceived](),
+		},
+		{
+			name: "unknown fields",
+			payload: `{"typeWebhook":"incomingMessageReceived","idMessage":"BAE5000000000001","chatType":"channel","priority":1,` +
+				`"senderData":{"chatId":"10000000@c.us","sender":"10000000@c.us"},` +
+				`"messageData":{"typeMessage":"pollMessage","pollMessageData":{"name":"Poll"},"textMessageData":{"textMessage":"Hello"}}}`,
+			wantType:         reflect.TypeFor[*notifications.IncomingMessageReceived](),
+			wantExtra:        []string{"chatType", "priority"},
+			wantMessageExtra: []string{"pollMessageData"},
+		},
+		{
+			name:      "unknown fields of a status",
+			payload:   `{"typeWebhook":"outgoingMessageStatus","idMessage":"BAE5000000000001","status":"read","chatId":"10000000@c.us","reaction":"+1"}`,
+			wantType:  reflect.TypeFor[*notifications.OutgoingMessageStatus](),
+			wantExtra: []string{"reaction"},
+		},
+		{
+			name:      "unknown type",
+			payload:   `{"typeWebhook":"pollVoteReceived","timestamp":1700000000,"idMessage":"BAE5000000000001","vote":{"option":"Yes"}}`,
+			wantType:  reflect.TypeFor[*notifications.UnknownWebhook](),
+			wantExtra: []string{"idMessage", "vote"},
+		},
+		{name: "no type", payload: `{"timestamp":1700000000}`, wantErr: true},
+		{name: "invalid JSON", payload: `{"typeWebhook":`, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := notifications.Decode([]byte(tt.payload))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decoded %T, want an error", w)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := reflect.TypeOf(w); got != tt.wantType {
+				t.Fatalf("decoded %v, want %v", got, tt.wantType)
+			}
+			if string(w.Raw()) != tt.payload {
+				t.Errorf("Raw returned %s, want the payload", w.Raw())
+			}
+
+			header := reflect.ValueOf(w).Elem().FieldByName("Header").Interface().(notifications.Header)
+			if got := keys(header.Extra); !slices.Equal(got, tt.wantExtra) {
+				t.Errorf("Extra has %q, want %q", got, tt.wantExtra)
+			}
+
+			m, ok := w.(notifications.MessageNotification)
+			if !ok {
+				return
+			}
+			data := m.Message().MessageData
+			if got := keys(data.Extra); !slices.Equal(got, tt.wantMessageExtra) {
+				t.Errorf("MessageData.Extra has %q, want %q", got, tt.wantMessageExtra)
+			}
+			if data.Text() != "Hello" {
+				t.Errorf("text %q, want the known fields decoded too", data.Text())
+			}
+		})
+	}
+}
+
+func TestDecodeNotification(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		body        string
+		wantReceipt int
+		wantNil     bool
+	}{
+		{"notification", `{"receiptId":7,"body":{"typeWebhook":"stateInstanceChanged","stateInstance":"authorized"}}`, 7, false},
+		{"empty queue", "null", 0, true},
+		{"empty body", " ", 0, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := notifications.DecodeNotification([]byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantNil {
+				if n != nil {
+					t.Errorf("got %+v, want nil", n)
+				}
+				return
+			}
+			if n.ReceiptId != tt.wantReceipt || n.Body.WebhookType() != "stateInstanceChanged" {
+				t.Errorf("got receipt %d of %s, want %d of stateInstanceChanged", n.ReceiptId, n.Body.WebhookType(), tt.wantReceipt)
+			}
+		})
+	}
+}
+
+// keys returns the sorted names of the fields, nil for none.
+func keys(fields map[string]json.RawMessage) []string {
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
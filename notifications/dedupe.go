@@ -0,0 +1,340 @@
+package notifications
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DedupeStore remembers which notifications were processed, so that a notification
+// delivered again, because DeleteNotification failed or a webhook was resent,
+// is not handled twice.
+type DedupeStore interface {
+	// Reserve atomically records the key and reports whether it was not recorded
+	// before or has expired, so that only one of concurrent deliveries is handled.
+	Reserve(ctx context.Context, key string) (bool, error)
+	// Release forgets a reserved key after its handler failed, so that
+	// the notification is handled when it is delivered again.
+	Release(ctx context.Context, key string) error
+}
+
+// Reserve every notification in the store before handling it, skip the ones
+// reserved before and release the ones whose handler failed.
+func WithDedupe(store DedupeStore) Option {
+	return func(c *config) error {
+		c.Dedupe = store
+		return nil
+	}
+}
+
+// DedupeKey identifies a notification across deliveries. Message notifications
+// are identified by idMessage, status notifications by idMessage and status,
+// others by receiptId or, for webhooks, by the hash of the payload.
+func DedupeKey(n *Notification) string {
+	switch w := n.Body.(type) {
+	case MessageNotification:
+		if w.Message().IdMessage != "" {
+			return w.WebhookType() + ":" + w.Message().IdMessage
+		}
+	case *OutgoingMessageStatus:
+		if w.IdMessage != "" {
+			return w.WebhookType() + ":" + w.IdMessage + ":" + w.Status
+		}
+	case *IncomingCall:
+		if w.IdMessage != "" {
+			return w.WebhookType() + ":" + w.IdMessage + ":" + w.Status
+		}
+	}
+
+	if n.ReceiptId != 0 {
+		return "receipt:" + strconv.Itoa(n.ReceiptId)
+	}
+
+	sum := sha256.Sum256(n.Body.Raw())
+	return n.Body.WebhookType() + ":" + hex.EncodeToString(sum[:])
+}
+
+// dispatch passes n to h unless the dedupe store has already reserved it.
+func (c *config) dispatch(ctx context.Context, h Handler, n *Notification) error {
+	if c.Dedupe == nil {
+		return h.HandleNotification(ctx, n)
+	}
+
+	key := DedupeKey(n)
+	reserved, err := c.Dedupe.Reserve(ctx, key)
+	if err != nil {
+		return fmt.Errorf("dedupe store: %w", err)
+	}
+	if !reserved {
+		return nil
+	}
+
+	if err := h.HandleNotification(ctx, n); err != nil {
+		// The handler error is returned anyway, so that the notification is delivered again.
+		if releaseErr := c.Dedupe.Release(context.WithoutCancel(ctx), key); releaseErr != nil {
+			c.ErrorHandler(fmt.Errorf("dedupe store: %w", releaseErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------ MemoryDedupeStore
+
+type dedupeEntry struct {
+	key     string
+	expires time.Time
+}
+
+// MemoryDedupeStore keeps up to size keys for ttl each, evicting the oldest
+// keys first. Expired keys are evicted on every insert, so with size <= 0
+// the number of keys is limited by ttl only.
+type MemoryDedupeStore struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func NewMemoryDedupeStore(size int, ttl time.Duration) *MemoryDedupeStore {
+	return &MemoryDedupeStore{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (s *MemoryDedupeStore) Reserve(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// A repeated delivery does not move the key, so the keys stay ordered by expiry.
+	if e, ok := s.entries[key]; ok && !now.After(e.Value.(*dedupeEntry).expires) {
+		return false, nil
+	}
+	s.mark(key, now, now.Add(s.ttl))
+	return true, nil
+}
+
+func (s *MemoryDedupeStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		s.order.Remove(e)
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+func (s *MemoryDedupeStore) mark(key string, now, expires time.Time) {
+	if e, ok := s.entries[key]; ok {
+		e.Value.(*dedupeEntry).expires = expires
+		s.order.MoveToFront(e)
+	} else {
+		s.entries[key] = s.order.PushFront(&dedupeEntry{key: key, expires: expires})
+	}
+
+	for oldest := s.order.Back(); oldest != nil; oldest = s.order.Back() {
+		if !now.After(oldest.Value.(*dedupeEntry).expires) && (s.size <= 0 || s.order.Len() <= s.size) {
+			break
+		}
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*dedupeEntry).key)
+	}
+}
+
+// Len returns the number of keys in the store, including the expired ones
+// that are not evicted yet.
+func (s *MemoryDedupeStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.order.Len()
+}
+
+// ------------------------------------------------------------------ FileDedupeStore
+
+// FileDedupeStore keeps keys for ttl in an append-only file, so that they survive
+// a restart. The file is compacted when it grows much larger than the live keys.
+// A key is written when it is reserved, so a notification whose handling was
+// interrupted by a crash is not handled again after the restart.
+type FileDedupeStore struct {
+	mu      sync.Mutex
+	path    string
+	ttl     time.Duration
+	file    *os.File
+	keys    map[string]time.Time
+	written int
+}
+
+// NewFileDedupeStore opens or creates the file at path and loads the keys that have not expired.
+func NewFileDedupeStore(path string, ttl time.Duration) (*FileDedupeStore, error) {
+	s := &FileDedupeStore{
+		path: path,
+		ttl:  ttl,
+		keys: make(map[string]time.Time),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileDedupeStore) Reserve(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if expires, ok := s.keys[key]; ok && !now.After(expires) {
+		return false, nil
+	}
+
+	expires := now.Add(s.ttl)
+	if err := s.write(key, expires); err != nil {
+		return false, err
+	}
+	s.keys[key] = expires
+
+	if s.written > 2*len(s.keys)+1000 {
+		// The key is already written, so the reservation holds.
+		if err := s.compact(); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+func (s *FileDedupeStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[key]; !ok {
+		return nil
+	}
+	// A line with an expired time removes the key when the file is loaded.
+	if err := s.write(key, time.Unix(0, 0)); err != nil {
+		return err
+	}
+	delete(s.keys, key)
+	return nil
+}
+
+func (s *FileDedupeStore) write(key string, expires time.Time) error {
+	if s.file == nil {
+		return fmt.Errorf("dedupe file %s is not open", s.path)
+	}
+	if _, err := fmt.Fprintf(s.file, "%d %s\n", expires.UnixNano(), strconv.Quote(key)); err != nil {
+		return err
+	}
+	s.written++
+	return nil
+}
+
+// Close closes the underlying file.
+func (s *FileDedupeStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileDedupeStore) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	now := time.Now()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		expiresField, quoted, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		nanos, err := strconv.ParseInt(expiresField, 10, 64)
+		if err != nil {
+			continue
+		}
+		key, err := strconv.Unquote(quoted)
+		if err != nil {
+			continue
+		}
+		if expires := time.Unix(0, nanos); expires.After(now) {
+			s.keys[key] = expires
+		} else {
+			delete(s.keys, key)
+		}
+	}
+	return scanner.Err()
+}
+
+// compact rewrites the file with the live keys only.
+func (s *FileDedupeStore) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	now := time.Now()
+	w := bufio.NewWriter(tmp)
+	for key, expires := range s.keys {
+		if expires.Before(now) {
+			delete(s.keys, key)
+			continue
+		}
+		fmt.Fprintf(w, "%d %s\n", expires.UnixNano(), strconv.Quote(key))
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// The file is closed before the rename, which fails on Windows for an open file,
+	// and reopened whether the rename succeeds or not.
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	renameErr := os.Rename(tmp.Name(), s.path)
+
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if renameErr != nil {
+		return errors.Join(renameErr, err)
+	}
+	if err != nil {
+		return err
+	}
+	s.written = len(s.keys)
+	return nil
+}
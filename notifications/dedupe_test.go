@@ -0,0 +1,184 @@
+package notifications_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/green-api/max-api-client-golang/notifications"
+)
+
+func TestDedupeStoreReserveConcurrently(t *testing.T) {
+	file, err := notifications.NewFileDedupeStore(filepath.Join(t.TempDir(), "dedupe.log"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	for _, tt := range []struct {
+		name  string
+		store notifications.DedupeStore
+	}{
+		{"memory", notifications.NewMemoryDedupeStore(100, time.Hour)},
+		{"file", file},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			var reserved atomic.Int32
+			var wg sync.WaitGroup
+			for range 20 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ok, err := tt.store.Reserve(ctx, "key")
+					if err != nil {
+						t.Error(err)
+					}
+					if ok {
+						reserved.Add(1)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := reserved.Load(); got != 1 {
+				t.Fatalf("key reserved %d times, want 1", got)
+			}
+
+			if err := tt.store.Release(ctx, "key"); err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := tt.store.Reserve(ctx, "key"); err != nil || !ok {
+				t.Errorf("Reserve after Release = %v, %v, want true", ok, err)
+			}
+		})
+	}
+}
+
+func TestFileDedupeStoreKeepsKeysAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedupe.log")
+	ctx := context.Background()
+
+	store, err := notifications.NewFileDedupeStore(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Reserve(ctx, "handled")
+	store.Reserve(ctx, "failed")
+	store.Release(ctx, "failed")
+	store.Close()
+
+	store, err = notifications.NewFileDedupeStore(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	for _, tt := range []struct {
+		key  string
+		want bool
+	}{
+		{"handled", false},
+		{"failed", true},
+		{"new", true},
+	} {
+		if ok, err := store.Reserve(ctx, tt.key); err != nil || ok != tt.want {
+			t.Errorf("Reserve(%q) = %v, %v, want %v", tt.key, ok, err, tt.want)
+		}
+	}
+}
+
+func TestMemoryDedupeStoreEvicts(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name    string
+		size    int
+		ttl     time.Duration
+		wait    time.Duration
+		wantLen int
+		// Whether the oldest key is reserved again after the last key.
+		wantOldest bool
+	}{
+		{"size", 2, time.Hour, 0, 2, true},
+		{"expired without size", 0, 20 * time.Millisecond, 50 * time.Millisecond, 1, true},
+		{"expired within size", 100, 20 * time.Millisecond, 50 * time.Millisecond, 1, true},
+		{"not expired without size", 0, time.Hour, 0, 4, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			store := notifications.NewMemoryDedupeStore(tt.size, tt.ttl)
+			for _, key := range []string{"key0", "key1", "key2"} {
+				store.Reserve(ctx, key)
+			}
+			time.Sleep(tt.wait)
+			store.Reserve(ctx, "last")
+
+			if got := store.Len(); got != tt.wantLen {
+				t.Errorf("%d keys in the store, want %d", got, tt.wantLen)
+			}
+			if ok, err := store.Reserve(ctx, "key0"); err != nil || ok != tt.wantOldest {
+				t.Errorf("Reserve of the oldest key = %v, %v, want %v", ok, err, tt.wantOldest)
+			}
+		})
+	}
+}
+
+func TestMemoryDedupeStoreRepeatedKeyExpires(t *testing.T) {
+	ctx := context.Background()
+	store := notifications.NewMemoryDedupeStore(0, 60*time.Millisecond)
+
+	store.Reserve(ctx, "repeated")
+	time.Sleep(30 * time.Millisecond)
+	if ok, _ := store.Reserve(ctx, "repeated"); ok {
+		t.Fatal("the key is reserved again before it expired")
+	}
+
+	// The repeated delivery does not extend the key.
+	time.Sleep(50 * time.Millisecond)
+	store.Reserve(ctx, "last")
+	if got := store.Len(); got != 1 {
+		t.Errorf("%d keys in the store, want the expired key evicted", got)
+	}
+}
+
+func TestWebhookDedupeConcurrentRedeliveries(t *testing.T) {
+	var calls atomic.Int32
+	h, err := notifications.NewWebhookHandler("", notifications.WithDedupe(notifications.NewMemoryDedupeStore(100, time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.HandleDefault(notifications.HandlerFunc(func(ctx context.Context, n *notifications.Notification) error {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}))
+
+	payload := `{"typeWebhook":"incomingMessageReceived","idMessage":"BAE5000000000001",` +
+		`"senderData":{"chatId":"10000000@c.us","sender":"10000000@c.us"},` +
+		`"messageData":{"typeMessage":"textMessage","textMessageData":{"textMessage":"Hello"}}}`
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload)))
+			if w.Code != http.StatusOK {
+				t.Errorf("status %d, want 200", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("handler called %d times, want 1", got)
+	}
+}
@@ -0,0 +1,164 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+var ErrDispatcherClosed = errors.New("dispatcher is closed")
+
+type job struct {
+	ctx context.Context
+	n   *Notification
+	// Receives the error of the handler, nil if nobody waits for it.
+	done chan error
+}
+
+// Dispatcher is a Handler that processes notifications on a pool of workers.
+// Notifications of the same chat always go to the same worker, so they are
+// handled in the order they were received, while different chats are handled
+// in parallel.
+//
+// HandleNotification returns the result of the handler once a worker has run it,
+// so the Consumer deletes a notification and the webhook handler acknowledges it
+// only after it is handled. The Consumer receives the next notification only after
+// the previous one is deleted, so notifications of different chats are handled in
+// parallel when they are delivered concurrently, e.g. by webhooks. For the Consumer,
+// use WithEarlyAck instead.
+type Dispatcher struct {
+	handler Handler
+	config  *config
+	queues  []chan job
+	// Holds a token for every notification that is queued or being handled.
+	pending chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher starts the given number of workers that pass notifications to h.
+// At most maxPending notifications are queued or being handled at a time;
+// HandleNotification waits for a free slot when the limit is reached.
+//
+// Add optional arguments by passing these functions:
+//
+//	WithErrorHandler(f func(error)) <- Function called with the errors of notifications whose caller stopped waiting.
+func NewDispatcher(h Handler, workers, maxPending int, options ...Option) (*Dispatcher, error) {
+	if workers < 1 {
+		return nil, fmt.Errorf("number of workers must be positive, got %v", workers)
+	}
+	if maxPending < 1 {
+		return nil, fmt.Errorf("max pending notifications must be positive, got %v", maxPending)
+	}
+
+	c, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDispatcher(h, workers, maxPending, c), nil
+}
+
+func newDispatcher(h Handler, workers, maxPending int, c *config) *Dispatcher {
+	d := &Dispatcher{
+		handler: h,
+		config:  c,
+		queues:  make([]chan job, workers),
+		pending: make(chan struct{}, maxPending),
+	}
+
+	for i := range d.queues {
+		// A queue never blocks, the pending slots limit the notifications.
+		d.queues[i] = make(chan job, maxPending)
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+
+	return d
+}
+
+// HandleNotification passes the notification to the worker of its chat and returns
+// the error of the handler. It waits while maxPending notifications are pending and
+// returns ctx.Err() if ctx is done first, in which case the handler may still run.
+func (d *Dispatcher) HandleNotification(ctx context.Context, n *Notification) error {
+	j := job{ctx: ctx, n: n, done: make(chan error, 1)}
+
+	if err := d.enqueue(ctx, j); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-j.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// queue passes the notification to the worker of its chat without waiting for
+// the handler, which gets a context that is not cancelled with ctx. It waits
+// while maxPending notifications are pending and returns ctx.Err() if ctx is done first.
+func (d *Dispatcher) queue(ctx context.Context, n *Notification) error {
+	return d.enqueue(ctx, job{ctx: context.WithoutCancel(ctx), n: n})
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, j job) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return ErrDispatcherClosed
+	}
+
+	select {
+	case d.pending <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	d.queues[d.worker(j.n)] <- j
+	return nil
+}
+
+// Close stops accepting notifications and waits until the pending ones are handled.
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	for _, q := range d.queues {
+		close(q)
+	}
+	d.mu.Unlock()
+
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) worker(n *Notification) int {
+	h := fnv.New32a()
+	h.Write([]byte(ChatId(n.Body)))
+	return int(h.Sum32() % uint32(len(d.queues)))
+}
+
+func (d *Dispatcher) work(queue chan job) {
+	defer d.wg.Done()
+
+	for j := range queue {
+		err := d.handler.HandleNotification(j.ctx, j.n)
+		<-d.pending
+
+		// Nobody reports the error of a notification whose caller stopped or did not wait.
+		if err != nil && (j.done == nil || j.ctx.Err() != nil) {
+			d.config.ErrorHandler(fmt.Errorf("handling notification %v: %w", j.n.ReceiptId, err))
+		}
+		if j.done != nil {
+			j.done <- err
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package notifications_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/green-api/max-api-client-golang/greenapitest"
+	"github.com/green-api/max-api-client-golang/notifications"
+)
+
+func TestDispatcherDeletesAfterHandling(t *testing.T) {
+	server := greenapitest.NewServer(greenapitest.WithMaxReceiveWait(50 * time.Millisecond))
+	defer server.Close()
+
+	instance := server.NewInstance()
+	instance.ReceiveText("10000000@c.us", "", "Hello")
+
+	var calls atomic.Int32
+	handled := make(chan struct{})
+	dispatcher, err := notifications.NewDispatcher(notifications.HandlerFunc(func(ctx context.Context, n *notifications.Notification) error {
+		if calls.Add(1) == 1 {
+			return errors.New("handler failed")
+		}
+		close(handled)
+		return nil
+	}), 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dispatcher.Close()
+
+	consumer, err := notifications.NewConsumer(instance.GreenAPI().Receiving(),
+		notifications.WithBackoff(time.Millisecond, time.Millisecond),
+		notifications.WithErrorHandler(func(error) {}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumer.HandleDefault(dispatcher)
+
+	if err := consumer.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-handled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the notification was not handled again after the failure")
+	}
+	consumer.Stop()
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("handler called %d times, want 2", got)
+	}
+	if got := instance.Notifications(); len(got) != 0 {
+		t.Errorf("%d notifications left in the queue, want 0", len(got))
+	}
+}
+
+func TestDispatcherLimitsPendingNotifications(t *testing.T) {
+	release := make(chan struct{})
+	var running atomic.Int32
+	dispatcher, err := notifications.NewDispatcher(notifications.HandlerFunc(func(ctx context.Context, n *notifications.Notification) error {
+		running.Add(1)
+		<-release
+		return nil
+	}), 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dispatcher.Close()
+
+	for _, chatId := range []string{"1@c.us", "2@c.us"} {
+		go dispatcher.HandleNotification(context.Background(), textNotification(chatId))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := dispatcher.HandleNotification(ctx, textNotification("3@c.us")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("third notification: got %v, want context.DeadlineExceeded", err)
+	}
+	if got := running.Load(); got > 2 {
+		t.Errorf("%d handlers running, want at most 2", got)
+	}
+	close(release)
+}
+
+func textNotification(chatId string) *notifications.Notification {
+	body, err := notifications.Decode([]byte(`{"typeWebhook":"incomingMessageReceived","idMessage":"` + chatId + `",` +
+		`"senderData":{"chatId":"` + chatId + `","sender":"` + chatId + `"},` +
+		`"messageData":{"typeMessage":"textMessage","textMessageData":{"textMessage":"Hello"}}}`))
+	if err != nil {
+		panic(err)
+	}
+	return &notifications.Notification{Body: body}
+}
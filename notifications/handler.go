@@ -0,0 +1,64 @@
+package notifications
+
+import (
+	"context"
+	"sync"
+)
+
+// Handler processes a notification. A returned error means the notification
+// was not processed and should be delivered again.
+type Handler interface {
+	HandleNotification(ctx context.Context, n *Notification) error
+}
+
+type HandlerFunc func(ctx context.Context, n *Notification) error
+
+func (f HandlerFunc) HandleNotification(ctx context.Context, n *Notification) error {
+	return f(ctx, n)
+}
+
+// Mux dispatches notifications to the handlers registered for their typeWebhook.
+// Notifications without a matching handler are acknowledged without processing.
+type Mux struct {
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	fallback Handler
+}
+
+// Handle registers the handler for notifications of the given typeWebhook,
+// e.g. TypeIncomingMessageReceived.
+func (m *Mux) Handle(typeWebhook string, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.handlers == nil {
+		m.handlers = make(map[string]Handler)
+	}
+	m.handlers[typeWebhook] = h
+}
+
+func (m *Mux) HandleFunc(typeWebhook string, f func(ctx context.Context, n *Notification) error) {
+	m.Handle(typeWebhook, HandlerFunc(f))
+}
+
+// HandleDefault registers the handler for notifications of types without their own handler.
+func (m *Mux) HandleDefault(h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.fallback = h
+}
+
+func (m *Mux) HandleNotification(ctx context.Context, n *Notification) error {
+	m.mu.RLock()
+	h, ok := m.handlers[n.Body.WebhookType()]
+	if !ok {
+		h = m.fallback
+	}
+	m.mu.RUnlock()
+
+	if h == nil {
+		return nil
+	}
+	return h.HandleNotification(ctx, n)
+}
@@ -0,0 +1,326 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+)
+
+// ErrMediaNotAllowed is returned for a file whose MIME type is not in WithMediaTypes.
+var ErrMediaNotAllowed = errors.New("media type is not allowed")
+
+// Media is the file of a message notification saved to a MediaStorage.
+type Media struct {
+	// Key of the file in the storage, the file path for DirStorage.
+	Key      string
+	FileName string
+	// MIME type detected from the first bytes of the file.
+	MimeType string
+	Size     int64
+
+	storage MediaStorage
+}
+
+// Open opens the file in the storage.
+func (m *Media) Open(ctx context.Context) (io.ReadCloser, error) {
+	return m.storage.Open(ctx, m.Key)
+}
+
+// MediaStorage stores the downloaded files, e.g. on disk, in memory or in an object storage.
+type MediaStorage interface {
+	// Save stores the content read from r under a key derived from name and returns the key.
+	Save(ctx context.Context, name string, r io.Reader) (key string, err error)
+	// Open opens the content stored under key.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// ------------------------------------------------------------------ DirStorage
+
+// DirStorage saves files to a local directory. Keys are file paths.
+type DirStorage struct {
+	Dir string
+}
+
+// NewDirStorage creates the directory if it does not exist.
+func NewDirStorage(dir string) (*DirStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirStorage{Dir: dir}, nil
+}
+
+func (s *DirStorage) Save(ctx context.Context, name string, r io.Reader) (string, error) {
+	path := filepath.Join(s.Dir, filepath.Base(name))
+
+	// The file appears under its name only when it is complete.
+	tmp, err := os.CreateTemp(s.Dir, filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (s *DirStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(key)
+}
+
+// ------------------------------------------------------------------ MemoryStorage
+
+// MemoryStorage keeps files in memory until they are deleted. Keys are the names.
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{files: make(map[string][]byte)}
+}
+
+func (s *MemoryStorage) Save(ctx context.Context, name string, r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[name] = data
+	return name, nil
+}
+
+func (s *MemoryStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.files[key]
+	if !ok {
+		return nil, fmt.Errorf("media %s: %w", key, fs.ErrNotExist)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Delete removes the file stored under key.
+func (s *MemoryStorage) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.files, key)
+}
+
+// ------------------------------------------------------------------ MediaDownloader
+
+type mediaConfig struct {
+	MaxSize int64
+	Types   []string
+}
+
+type MediaOption func(*mediaConfig) error
+
+// Maximum size of a file in bytes. Larger files are not downloaded. Unlimited by default.
+func WithMaxMediaSize(maxSize int64) MediaOption {
+	return func(c *mediaConfig) error {
+		if maxSize <= 0 {
+			return fmt.Errorf("max media size must be positive, got %v", maxSize)
+		}
+		c.MaxSize = maxSize
+		return nil
+	}
+}
+
+// MIME types of the files to download, e.g. "image/*" or "application/pdf".
+// Files of other types are not downloaded. All types are allowed by default.
+func WithMediaTypes(types ...string) MediaOption {
+	return func(c *mediaConfig) error {
+		c.Types = append(c.Types, types...)
+		return nil
+	}
+}
+
+// MediaDownloader downloads the files of incoming and outgoing file messages
+// (images, videos, documents, audio) to a storage and attaches them
+// to the notification as FileMessageData.Media.
+type MediaDownloader struct {
+	receiving greenapi.ReceivingCategory
+	storage   MediaStorage
+	config    *mediaConfig
+}
+
+// NewMediaDownloader creates a downloader that fetches files with the HTTP client
+// of the given category and saves them to storage.
+//
+// Add optional arguments by passing these functions:
+//
+//	WithMaxMediaSize(maxSize int64) <- Maximum size of a file in bytes. Unlimited by default.
+//	WithMediaTypes(types ...string) <- MIME types of the files to download, e.g. "image/*". All types by default.
+func NewMediaDownloader(receiving greenapi.ReceivingCategory, storage MediaStorage, options ...MediaOption) (*MediaDownloader, error) {
+	c := &mediaConfig{}
+	for _, o := range options {
+		err := o(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &MediaDownloader{
+		receiving: receiving,
+		storage:   storage,
+		config:    c,
+	}, nil
+}
+
+// Download saves the file of a message notification and sets FileMessageData.Media.
+// Notifications without a file are left as they are. For a file that is too large
+// or not allowed, an error wrapping greenapi.ErrFileTooLarge or ErrMediaNotAllowed is returned.
+func (d *MediaDownloader) Download(ctx context.Context, n *Notification) error {
+	m, ok := n.Body.(MessageNotification)
+	if !ok {
+		return nil
+	}
+	message := m.Message()
+	file := message.MessageData.FileMessageData
+	if file == nil || file.DownloadUrl == "" || file.Media != nil {
+		return nil
+	}
+
+	if file.MimeType != "" && !d.allowed(file.MimeType) {
+		return fmt.Errorf("%w: %s", ErrMediaNotAllowed, file.MimeType)
+	}
+
+	var options []greenapi.DownloadFileToOption
+	if d.config.MaxSize > 0 {
+		options = append(options, greenapi.OptionalMaxSize(d.config.MaxSize))
+	}
+
+	pr, pw := io.Pipe()
+	type result struct {
+		file *greenapi.DownloadedFile
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		downloaded, err := d.receiving.DownloadUrlTo(ctx, file.DownloadUrl, pw, options...)
+		pw.CloseWithError(err)
+		done <- result{downloaded, err}
+	}()
+
+	// The type is checked before anything is saved.
+	detected, content, err := greenapi.DetectMimeType(pr)
+	if err == nil && !d.allowed(detected) {
+		err = fmt.Errorf("%w: %s", ErrMediaNotAllowed, detected)
+	}
+
+	var key string
+	if err == nil {
+		key, err = d.storage.Save(ctx, mediaName(message.IdMessage, file.FileName), content)
+	}
+	pr.CloseWithError(err)
+
+	// A failed download also fails the Save, which read from the pipe.
+	res := <-done
+	if res.err != nil && (err == nil || errors.Is(err, res.err)) {
+		err = fmt.Errorf("downloading %s: %w", file.DownloadUrl, res.err)
+	}
+	if err != nil {
+		return err
+	}
+
+	fileName := file.FileName
+	if fileName == "" {
+		fileName = res.file.FileName
+	}
+
+	file.Media = &Media{
+		Key:      key,
+		FileName: fileName,
+		MimeType: detected,
+		Size:     res.file.Size,
+		storage:  d.storage,
+	}
+	return nil
+}
+
+// Handler returns a Handler that downloads the file of a notification, then passes
+// the notification to next. Files that are too large or not allowed are skipped,
+// so next gets the notification without Media. Other errors are returned, so that
+// the notification is received again.
+func (d *MediaDownloader) Handler(next Handler) Handler {
+	return HandlerFunc(func(ctx context.Context, n *Notification) error {
+		if err := d.download(ctx, n); err != nil {
+			return err
+		}
+		return next.HandleNotification(ctx, n)
+	})
+}
+
+// Middleware returns router middleware that works like Handler.
+func (d *MediaDownloader) Middleware() Middleware {
+	return func(next RouteHandler) RouteHandler {
+		return func(c *Context) error {
+			if err := d.download(c.Context(), c.Notification); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+func (d *MediaDownloader) download(ctx context.Context, n *Notification) error {
+	err := d.Download(ctx, n)
+	if errors.Is(err, greenapi.ErrFileTooLarge) || errors.Is(err, ErrMediaNotAllowed) {
+		return nil
+	}
+	return err
+}
+
+func (d *MediaDownloader) allowed(mimeType string) bool {
+	if len(d.config.Types) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	for _, t := range d.config.Types {
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+func mediaName(idMessage, fileName string) string {
+	fileName = filepath.Base(fileName)
+	if fileName == "." || fileName == string(filepath.Separator) {
+		return idMessage
+	}
+	return idMessage + "_" + fileName
+}
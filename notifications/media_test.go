@@ -0,0 +1,180 @@
+package notifications_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/greenapitest"
+	"github.com/green-api/max-api-client-golang/notifications"
+)
+
+var pngData = []byte("\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 100))
+
+func TestMediaDownloaderDownload(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		fileName string
+		data     []byte
+		options  []notifications.MediaOption
+		wantType string
+		wantErr  error
+		// Whether the file is requested from the media host.
+		wantDownload bool
+	}{
+		{name: "any type", fileName: "notes.txt", data: []byte("Hello"), wantType: "text/plain; charset=utf-8", wantDownload: true},
+		{name: "allowed type", fileName: "photo.png", data: pngData,
+			options:  []notifications.MediaOption{notifications.WithMediaTypes("application/pdf", "image/*"), notifications.WithMaxMediaSize(int64(len(pngData)))},
+			wantType: "image/png", wantDownload: true},
+		{name: "too large", fileName: "photo.png", data: pngData,
+			options: []notifications.MediaOption{notifications.WithMaxMediaSize(int64(len(pngData)) - 1)},
+			wantErr: greenapi.ErrFileTooLarge, wantDownload: true},
+		// The declared type is checked before the download.
+		{name: "declared type not allowed", fileName: "photo.png", data: pngData,
+			options: []notifications.MediaOption{notifications.WithMediaTypes("application/pdf")},
+			wantErr: notifications.ErrMediaNotAllowed},
+		// The detected type is checked before the file is saved.
+		{name: "detected type not allowed", fileName: "photo.png", data: []byte("not an image"),
+			options: []notifications.MediaOption{notifications.WithMediaTypes("image/*")},
+			wantErr: notifications.ErrMediaNotAllowed, wantDownload: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greenapitest.NewServer()
+			defer server.Close()
+			instance := server.NewInstance()
+			idMessage := instance.ReceiveFile("10000000@c.us", "", tt.fileName, tt.data)
+
+			storage := notifications.NewMemoryStorage()
+			downloader, err := notifications.NewMediaDownloader(instance.GreenAPI().Receiving(), storage, tt.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			n := lastNotification(t, instance)
+			err = downloader.Download(context.Background(), n)
+
+			downloaded := false
+			for _, r := range server.Requests() {
+				downloaded = downloaded || r.Media
+			}
+			if downloaded != tt.wantDownload {
+				t.Errorf("file downloaded: %v, want %v", downloaded, tt.wantDownload)
+			}
+
+			media := n.Body.(notifications.MessageNotification).Message().MessageData.FileMessageData.Media
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				if media != nil {
+					t.Errorf("Media is set to %+v after the error", media)
+				}
+				if _, err := storage.Open(context.Background(), idMessage+"_"+tt.fileName); !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("the file is saved to the storage after the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if media == nil {
+				t.Fatal("Media is not set")
+			}
+			if media.FileName != tt.fileName || media.MimeType != tt.wantType || media.Size != int64(len(tt.data)) {
+				t.Errorf("Media %q %q %d, want %q %q %d", media.FileName, media.MimeType, media.Size, tt.fileName, tt.wantType, len(tt.data))
+			}
+			r, err := media.Open(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			if content, err := io.ReadAll(r); err != nil || string(content) != string(tt.data) {
+				t.Errorf("stored %q, %v, want the content of the file", content, err)
+			}
+		})
+	}
+}
+
+func TestMediaDownloaderSkipsFiles(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+	GreenAPI := instance.GreenAPI()
+
+	downloader, err := notifications.NewMediaDownloader(GreenAPI.Receiving(), notifications.NewMemoryStorage(),
+		notifications.WithMediaTypes("image/*"), notifications.WithMaxMediaSize(int64(len(pngData))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		fileName  string
+		data      []byte
+		wantMedia bool
+	}{
+		{"downloaded", "photo.png", pngData, true},
+		{"too large", "large.png", []byte(string(pngData) + "\x00"), false},
+		{"not allowed", "notes.txt", []byte("Hello"), false},
+	} {
+		instance.ReceiveFile("10000000@c.us", "", tt.fileName, tt.data)
+		n := lastNotification(t, instance)
+
+		for _, h := range []struct {
+			name   string
+			handle func(next func(n *notifications.Notification)) error
+		}{
+			{"Handler", func(next func(n *notifications.Notification)) error {
+				return downloader.Handler(notifications.HandlerFunc(func(ctx context.Context, n *notifications.Notification) error {
+					next(n)
+					return nil
+				})).HandleNotification(context.Background(), n)
+			}},
+			{"Middleware", func(next func(n *notifications.Notification)) error {
+				router := notifications.NewRouter(GreenAPI.Sending())
+				router.Use(downloader.Middleware())
+				router.OnMessage(func(c *notifications.Context) error {
+					next(c.Notification)
+					return nil
+				})
+				return router.HandleNotification(context.Background(), n)
+			}},
+		} {
+			t.Run(tt.name+" "+h.name, func(t *testing.T) {
+				file := n.Body.(notifications.MessageNotification).Message().MessageData.FileMessageData
+				file.Media = nil
+
+				called := false
+				err := h.handle(func(n *notifications.Notification) {
+					called = true
+					if got := file.Media != nil; got != tt.wantMedia {
+						t.Errorf("Media set: %v, want %v", got, tt.wantMedia)
+					}
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !called {
+					t.Error("the next handler is not called")
+				}
+			})
+		}
+	}
+}
+
+// lastNotification decodes the last notification in the queue of the instance.
+func lastNotification(t *testing.T, instance *greenapitest.Instance) *notifications.Notification {
+	t.Helper()
+
+	queue := instance.Notifications()
+	body, err := notifications.Decode(queue[len(queue)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &notifications.Notification{Body: body}
+}
@@ -0,0 +1,112 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Logging writes the type, chat and result of every notification to the logger.
+func Logging(logger *log.Logger) Middleware {
+	return func(next RouteHandler) RouteHandler {
+		return func(c *Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				logger.Printf("%s %s: %v (%s)", c.Notification.Body.WebhookType(), c.ChatId(), err, time.Since(start))
+			} else {
+				logger.Printf("%s %s: ok (%s)", c.Notification.Body.WebhookType(), c.ChatId(), time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// Recovery turns a panic in the next handlers into an error, so that one broken
+// notification does not stop the consumer or the webhook server.
+func Recovery() Middleware {
+	return func(next RouteHandler) RouteHandler {
+		return func(c *Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+				}
+			}()
+			return next(c)
+		}
+	}
+}
+
+// AllowChats ignores notifications from chats not in the list.
+func AllowChats(chatIds ...string) Middleware {
+	return func(next RouteHandler) RouteHandler {
+		return func(c *Context) error {
+			if !slices.Contains(chatIds, c.ChatId()) {
+				return nil
+			}
+			return next(c)
+		}
+	}
+}
+
+// AllowSenders ignores messages whose sender is not in the list,
+// which restricts group chats to certain participants.
+func AllowSenders(senders ...string) Middleware {
+	return func(next RouteHandler) RouteHandler {
+		return func(c *Context) error {
+			if c.Message == nil || !slices.Contains(senders, c.Message.SenderData.Sender) {
+				return nil
+			}
+			return next(c)
+		}
+	}
+}
+
+// RateLimitPerChat lets through at most limit notifications of a chat in every
+// period and ignores the rest.
+func RateLimitPerChat(limit int, period time.Duration) Middleware {
+	type window struct {
+		start time.Time
+		count int
+	}
+
+	var mu sync.Mutex
+	windows := make(map[string]*window)
+
+	allow := func(chatId string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now := time.Now()
+		if len(windows) > 10000 {
+			for id, w := range windows {
+				if now.Sub(w.start) >= period {
+					delete(windows, id)
+				}
+			}
+		}
+
+		w, ok := windows[chatId]
+		if !ok || now.Sub(w.start) >= period {
+			windows[chatId] = &window{start: now, count: 1}
+			return true
+		}
+		if w.count >= limit {
+			return false
+		}
+		w.count++
+		return true
+	}
+
+	return func(next RouteHandler) RouteHandler {
+		return func(c *Context) error {
+			if !allow(c.ChatId()) {
+				return nil
+			}
+			return next(c)
+		}
+	}
+}
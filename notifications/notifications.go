@@ -0,0 +1,279 @@
+// Package notifications contains typed incoming notifications of the API,
+// received either with ReceiveNotification or on the webhook server.
+//
+// https://green-api.com/v3/docs/api/receiving/notifications-format/
+package notifications
+
+import "encoding/json"
+
+// Values of the typeWebhook field.
+const (
+	TypeIncomingMessageReceived    = "incomingMessageReceived"
+	TypeOutgoingMessageReceived    = "outgoingMessageReceived"
+	TypeOutgoingAPIMessageReceived = "outgoingAPIMessageReceived"
+	TypeOutgoingMessageStatus      = "outgoingMessageStatus"
+	TypeStateInstanceChanged       = "stateInstanceChanged"
+	TypeIncomingCall               = "incomingCall"
+)
+
+// Values of the typeMessage field.
+const (
+	TypeTextMessage         = "textMessage"
+	TypeExtendedTextMessage = "extendedTextMessage"
+	TypeQuotedMessage       = "quotedMessage"
+	TypeImageMessage        = "imageMessage"
+	TypeVideoMessage        = "videoMessage"
+	TypeDocumentMessage     = "documentMessage"
+	TypeAudioMessage        = "audioMessage"
+	TypeLocationMessage     = "locationMessage"
+	TypeContactMessage      = "contactMessage"
+)
+
+// Values of the status field of OutgoingMessageStatus.
+const (
+	StatusSent       = "sent"
+	StatusDelivered  = "delivered"
+	StatusRead       = "read"
+	StatusFailed     = "failed"
+	StatusNoAccount  = "noAccount"
+	StatusNotInGroup = "notInGroup"
+	StatusYellowCard = "yellowCard"
+)
+
+// Webhook is the body of a notification. Use a type switch to get the concrete type:
+//
+//	switch w := n.Body.(type) {
+//	case *notifications.IncomingMessageReceived:
+//	case *notifications.OutgoingMessageStatus:
+//	case *notifications.UnknownWebhook:
+//	}
+type Webhook interface {
+	WebhookType() string
+	// Raw returns the original JSON payload.
+	Raw() json.RawMessage
+}
+
+// Notification is an entry of the notifications queue.
+// ReceiptId is zero for notifications received by webhook.
+type Notification struct {
+	ReceiptId int
+	Body      Webhook
+}
+
+type InstanceData struct {
+	IdInstance   int64  `json:"idInstance"`
+	Wid          string `json:"wid"`
+	TypeInstance string `json:"typeInstance"`
+}
+
+// Header contains the fields common to all notifications.
+type Header struct {
+	TypeWebhook  string       `json:"typeWebhook"`
+	InstanceData InstanceData `json:"instanceData"`
+	Timestamp    int64        `json:"timestamp"`
+	// Fields of the payload that this version of the library does not know about.
+	Extra map[string]json.RawMessage `json:"-"`
+
+	raw json.RawMessage
+}
+
+func (h *Header) WebhookType() string {
+	return h.TypeWebhook
+}
+
+func (h *Header) Raw() json.RawMessage {
+	return h.raw
+}
+
+// ------------------------------------------------------------------ Messages
+
+type SenderData struct {
+	ChatId            string `json:"chatId"`
+	ChatName          string `json:"chatName"`
+	Sender            string `json:"sender"`
+	SenderName        string `json:"senderName"`
+	SenderContactName string `json:"senderContactName"`
+}
+
+// MessageWebhook contains the fields of the incoming, outgoing and outgoing API message notifications.
+type MessageWebhook struct {
+	Header
+	IdMessage   string      `json:"idMessage"`
+	SenderData  SenderData  `json:"senderData"`
+	MessageData MessageData `json:"messageData"`
+}
+
+// Message returns the common part of a message notification.
+func (m *MessageWebhook) Message() *MessageWebhook {
+	return m
+}
+
+// MessageNotification is implemented by all message notifications.
+type MessageNotification interface {
+	Webhook
+	Message() *MessageWebhook
+}
+
+// https://green-api.com/v3/docs/api/receiving/notifications-format/incoming-message/
+type IncomingMessageReceived struct {
+	MessageWebhook
+}
+
+// A message sent from the phone.
+type OutgoingMessageReceived struct {
+	MessageWebhook
+}
+
+// A message sent with the API.
+type OutgoingAPIMessageReceived struct {
+	MessageWebhook
+}
+
+// MessageData holds one of the typed message contents depending on TypeMessage.
+type MessageData struct {
+	TypeMessage string `json:"typeMessage"`
+
+	TextMessageData         *TextMessageData         `json:"textMessageData,omitempty"`
+	ExtendedTextMessageData *ExtendedTextMessageData `json:"extendedTextMessageData,omitempty"`
+	FileMessageData         *FileMessageData         `json:"fileMessageData,omitempty"`
+	LocationMessageData     *LocationMessageData     `json:"locationMessageData,omitempty"`
+	ContactMessageData      *ContactMessageData      `json:"contactMessageData,omitempty"`
+	// The message being replied to, present if TypeMessage is "quotedMessage".
+	QuotedMessage *QuotedMessage `json:"quotedMessage,omitempty"`
+
+	// Fields of the payload that this version of the library does not know about.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type TextMessageData struct {
+	TextMessage string `json:"textMessage"`
+}
+
+type ExtendedTextMessageData struct {
+	Text            string `json:"text"`
+	Description     string `json:"description,omitempty"`
+	Title           string `json:"title,omitempty"`
+	PreviewType     string `json:"previewType,omitempty"`
+	JpegThumbnail   string `json:"jpegThumbnail,omitempty"`
+	StanzaId        string `json:"stanzaId,omitempty"`
+	Participant     string `json:"participant,omitempty"`
+	IsForwarded     bool   `json:"isForwarded,omitempty"`
+	ForwardingScore int    `json:"forwardingScore,omitempty"`
+}
+
+// FileMessageData is the content of image, video, document and audio messages.
+type FileMessageData struct {
+	DownloadUrl     string `json:"downloadUrl"`
+	Caption         string `json:"caption,omitempty"`
+	FileName        string `json:"fileName"`
+	JpegThumbnail   string `json:"jpegThumbnail,omitempty"`
+	MimeType        string `json:"mimeType"`
+	IsForwarded     bool   `json:"isForwarded,omitempty"`
+	ForwardingScore int    `json:"forwardingScore,omitempty"`
+
+	// The downloaded file, set by MediaDownloader.
+	Media *Media `json:"-"`
+}
+
+type LocationMessageData struct {
+	NameLocation  string  `json:"nameLocation,omitempty"`
+	Address       string  `json:"address,omitempty"`
+	JpegThumbnail string  `json:"jpegThumbnail,omitempty"`
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+}
+
+type ContactMessageData struct {
+	DisplayName string `json:"displayName"`
+	Vcard       string `json:"vcard"`
+}
+
+type QuotedMessage struct {
+	StanzaId    string `json:"stanzaId"`
+	Participant string `json:"participant"`
+	TypeMessage string `json:"typeMessage"`
+
+	TextMessage   string  `json:"textMessage,omitempty"`
+	DownloadUrl   string  `json:"downloadUrl,omitempty"`
+	Caption       string  `json:"caption,omitempty"`
+	FileName      string  `json:"fileName,omitempty"`
+	NameLocation  string  `json:"nameLocation,omitempty"`
+	Latitude      float64 `json:"latitude,omitempty"`
+	Longitude     float64 `json:"longitude,omitempty"`
+	DisplayName   string  `json:"displayName,omitempty"`
+	Vcard         string  `json:"vcard,omitempty"`
+	JpegThumbnail string  `json:"jpegThumbnail,omitempty"`
+}
+
+// Content returns the typed content of the message: *TextMessageData,
+// *ExtendedTextMessageData, *FileMessageData, *LocationMessageData or
+// *ContactMessageData. It returns nil for unknown message types and empty content.
+func (m *MessageData) Content() any {
+	switch m.TypeMessage {
+	case TypeTextMessage:
+		if m.TextMessageData != nil {
+			return m.TextMessageData
+		}
+	case TypeExtendedTextMessage, TypeQuotedMessage:
+		if m.ExtendedTextMessageData != nil {
+			return m.ExtendedTextMessageData
+		}
+	case TypeImageMessage, TypeVideoMessage, TypeDocumentMessage, TypeAudioMessage:
+		if m.FileMessageData != nil {
+			return m.FileMessageData
+		}
+	case TypeLocationMessage:
+		if m.LocationMessageData != nil {
+			return m.LocationMessageData
+		}
+	case TypeContactMessage:
+		if m.ContactMessageData != nil {
+			return m.ContactMessageData
+		}
+	}
+	return nil
+}
+
+// Text returns the text of a text, extended text or quoted message, or the caption of a file.
+func (m *MessageData) Text() string {
+	switch {
+	case m.TextMessageData != nil:
+		return m.TextMessageData.TextMessage
+	case m.ExtendedTextMessageData != nil:
+		return m.ExtendedTextMessageData.Text
+	case m.FileMessageData != nil:
+		return m.FileMessageData.Caption
+	}
+	return ""
+}
+
+// ------------------------------------------------------------------ Statuses
+
+// https://green-api.com/v3/docs/api/receiving/notifications-format/outgoing-message/OutgoingMessageStatus/
+type OutgoingMessageStatus struct {
+	Header
+	ChatId      string `json:"chatId"`
+	IdMessage   string `json:"idMessage"`
+	Status      string `json:"status"`
+	Description string `json:"description,omitempty"`
+	SendByApi   bool   `json:"sendByApi"`
+}
+
+// https://green-api.com/v3/docs/api/receiving/notifications-format/StateInstanceChanged/
+type StateInstanceChanged struct {
+	Header
+	StateInstance string `json:"stateInstance"`
+}
+
+type IncomingCall struct {
+	Header
+	From      string `json:"from"`
+	Status    string `json:"status"`
+	IdMessage string `json:"idMessage"`
+}
+
+// UnknownWebhook is a notification of a type this version of the library does not know.
+// Its payload is available with Raw and Extra.
+type UnknownWebhook struct {
+	Header
+}
@@ -0,0 +1,74 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+type config struct {
+	ReceiveTimeout int
+	MinBackoff     time.Duration
+	MaxBackoff     time.Duration
+	ErrorHandler   func(error)
+	Dedupe         DedupeStore
+	// Workers and pending notifications of the consumer in the early ack mode, 0 if off.
+	AckWorkers    int
+	AckMaxPending int
+}
+
+type Option func(*config) error
+
+// Notification waiting timeout of ReceiveNotification in seconds, from 5 to 60 (20 by default).
+func WithReceiveTimeout(seconds int) Option {
+	return func(c *config) error {
+		if seconds < 5 || seconds > 60 {
+			return fmt.Errorf("receive timeout must be from 5 to 60 seconds, got %v", seconds)
+		}
+		c.ReceiveTimeout = seconds
+		return nil
+	}
+}
+
+// Delay after a failed request or handler, doubled up to max on every next failure
+// (from 1 to 30 seconds by default).
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *config) error {
+		if min <= 0 || max < min {
+			return fmt.Errorf("backoff must satisfy 0 < min <= max, got %v and %v", min, max)
+		}
+		c.MinBackoff = min
+		c.MaxBackoff = max
+		return nil
+	}
+}
+
+// Function called with every error of receiving, decoding or handling a notification.
+// Errors are written with the standard logger by default.
+func WithErrorHandler(f func(error)) Option {
+	return func(c *config) error {
+		if f == nil {
+			return fmt.Errorf("nil error handler")
+		}
+		c.ErrorHandler = f
+		return nil
+	}
+}
+
+func newConfig(options []Option) (*config, error) {
+	c := &config{
+		ReceiveTimeout: 20,
+		MinBackoff:     time.Second,
+		MaxBackoff:     30 * time.Second,
+		ErrorHandler: func(err error) {
+			log.Printf("notifications: %v", err)
+		},
+	}
+	for _, o := range options {
+		err := o(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,214 @@
+package notifications
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+)
+
+// Context is passed to the route handlers. It holds the notification
+// and helpers bound to the chat it came from.
+type Context struct {
+	ctx     context.Context
+	sending greenapi.SendingCategory
+
+	Notification *Notification
+	// Message is the common part of a message notification, nil for other notifications.
+	Message *MessageWebhook
+	// Arguments after the command name, set by the Command filter.
+	Args string
+	// Submatches of the regular expression, set by the TextMatches filter.
+	Matches []string
+}
+
+// Context returns the context of the notification handling.
+func (c *Context) Context() context.Context {
+	return c.ctx
+}
+
+// ChatId returns the chat the notification belongs to.
+func (c *Context) ChatId() string {
+	return ChatId(c.Notification.Body)
+}
+
+// Text returns the text or the caption of the message, or an empty string.
+func (c *Context) Text() string {
+	if c.Message == nil {
+		return ""
+	}
+	return c.Message.MessageData.Text()
+}
+
+// Reply sends a message to the chat quoting the received message.
+// Options are applied after the quote, so OptionalQuotedMessageId overrides it.
+func (c *Context) Reply(message string, options ...greenapi.SendMessageOption) (*greenapi.APIResponse, error) {
+	if c.Message != nil && c.Message.IdMessage != "" {
+		options = append([]greenapi.SendMessageOption{greenapi.OptionalQuotedMessageId(c.Message.IdMessage)}, options...)
+	}
+	return c.Send(message, options...)
+}
+
+// Send sends a message to the chat without quoting.
+func (c *Context) Send(message string, options ...greenapi.SendMessageOption) (*greenapi.APIResponse, error) {
+	return c.sending.SendMessageCtx(c.ctx, c.ChatId(), message, options...)
+}
+
+type RouteHandler func(c *Context) error
+
+// Middleware wraps the handling of every notification passed to the router.
+type Middleware func(next RouteHandler) RouteHandler
+
+// Filter reports whether a route matches the notification.
+type Filter func(c *Context) bool
+
+type route struct {
+	filters []Filter
+	handler RouteHandler
+}
+
+// Router is a Handler that passes a notification to the first route whose
+// filters all match it, through the middleware chain. Notifications that match
+// no route are ignored.
+type Router struct {
+	sending    greenapi.SendingCategory
+	middleware []Middleware
+	routes     []route
+}
+
+// NewRouter creates a router whose Context.Reply sends messages with the given category.
+func NewRouter(sending greenapi.SendingCategory) *Router {
+	return &Router{sending: sending}
+}
+
+// Use appends middleware to the chain. The first added middleware runs first.
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// On adds a route for notifications matching all filters.
+func (r *Router) On(h RouteHandler, filters ...Filter) {
+	r.routes = append(r.routes, route{filters: filters, handler: h})
+}
+
+// OnMessage adds a route for incoming messages matching all filters.
+func (r *Router) OnMessage(h RouteHandler, filters ...Filter) {
+	r.On(h, append([]Filter{WebhookTypeIs(TypeIncomingMessageReceived)}, filters...)...)
+}
+
+// OnCommand adds a route for incoming messages starting with the command, e.g. "/start".
+func (r *Router) OnCommand(command string, h RouteHandler, filters ...Filter) {
+	r.OnMessage(h, append([]Filter{Command(command)}, filters...)...)
+}
+
+// OnText adds a route for incoming messages whose text matches the regular expression.
+func (r *Router) OnText(re *regexp.Regexp, h RouteHandler, filters ...Filter) {
+	r.OnMessage(h, append([]Filter{TextMatches(re)}, filters...)...)
+}
+
+func (r *Router) HandleNotification(ctx context.Context, n *Notification) error {
+	c := &Context{
+		ctx:          ctx,
+		sending:      r.sending,
+		Notification: n,
+	}
+	if m, ok := n.Body.(MessageNotification); ok {
+		c.Message = m.Message()
+	}
+
+	h := r.dispatch
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		h = r.middleware[i](h)
+	}
+	return h(c)
+}
+
+func (r *Router) dispatch(c *Context) error {
+	for _, route := range r.routes {
+		c.Args, c.Matches = "", nil
+		if matchAll(c, route.filters) {
+			return route.handler(c)
+		}
+	}
+	return nil
+}
+
+func matchAll(c *Context, filters []Filter) bool {
+	for _, f := range filters {
+		if !f(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ------------------------------------------------------------------ Filters
+
+// WebhookTypeIs matches notifications of any of the given types, e.g. TypeIncomingMessageReceived.
+func WebhookTypeIs(types ...string) Filter {
+	return func(c *Context) bool {
+		return slices.Contains(types, c.Notification.Body.WebhookType())
+	}
+}
+
+// MessageTypeIs matches messages of any of the given types, e.g. TypeTextMessage.
+func MessageTypeIs(types ...string) Filter {
+	return func(c *Context) bool {
+		return c.Message != nil && slices.Contains(types, c.Message.MessageData.TypeMessage)
+	}
+}
+
+// ChatIdIs matches notifications from any of the given chats.
+func ChatIdIs(chatIds ...string) Filter {
+	return func(c *Context) bool {
+		return slices.Contains(chatIds, c.ChatId())
+	}
+}
+
+// GroupChat matches notifications from group chats.
+func GroupChat() Filter {
+	return func(c *Context) bool {
+		return greenapi.ChatId(c.ChatId()).IsGroup()
+	}
+}
+
+// PersonalChat matches notifications from personal chats.
+func PersonalChat() Filter {
+	return func(c *Context) bool {
+		chatId := c.ChatId()
+		return chatId != "" && !greenapi.ChatId(chatId).IsGroup()
+	}
+}
+
+// TextMatches matches messages whose text matches the regular expression
+// and stores the submatches in Context.Matches.
+func TextMatches(re *regexp.Regexp) Filter {
+	return func(c *Context) bool {
+		matches := re.FindStringSubmatch(c.Text())
+		if matches == nil {
+			return false
+		}
+		c.Matches = matches
+		return true
+	}
+}
+
+// Command matches messages that start with the command, e.g. "/start", followed
+// by a space or the end of the text, and stores the rest of the text in Context.Args.
+func Command(command string) Filter {
+	if !strings.HasPrefix(command, "/") {
+		command = "/" + command
+	}
+
+	return func(c *Context) bool {
+		text := strings.TrimSpace(c.Text())
+		rest, ok := strings.CutPrefix(text, command)
+		if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\n') {
+			return false
+		}
+		c.Args = strings.TrimSpace(rest)
+		return true
+	}
+}
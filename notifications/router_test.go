@@ -0,0 +1,183 @@
+package notifications_test
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/greenapitest"
+	"github.com/green-api/max-api-client-golang/notifications"
+)
+
+func TestRouter(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+	GreenAPI := instance.GreenAPI()
+
+	router := notifications.NewRouter(GreenAPI.Sending())
+	reply := func(name string) notifications.RouteHandler {
+		return func(c *notifications.Context) error {
+			_, err := c.Reply(name + " " + c.Args + " " + strings.Join(c.Matches, ","))
+			return err
+		}
+	}
+	router.OnCommand("/start", reply("start"))
+	router.OnCommand("help", reply("help"), notifications.PersonalChat())
+	router.OnText(regexp.MustCompile(`^order (\d+)$`), reply("order"))
+	router.OnMessage(reply("group"), notifications.GroupChat())
+	router.OnMessage(reply("personal"), notifications.PersonalChat())
+
+	const group = "120363000000000001@g.us"
+	for _, tt := range []struct {
+		name   string
+		chatId string
+		text   string
+		// Text of the reply, empty if no route matches.
+		want string
+	}{
+		{"command", "10000000@c.us", "/start", "start  "},
+		{"command with arguments", "10000000@c.us", " /start now please ", "start now please "},
+		{"longer command", "10000000@c.us", "/starting", "personal  "},
+		{"command without slash", "10000000@c.us", "/help me", "help me "},
+		{"command filtered by chat", group, "/help me", "group  "},
+		{"text matches", "10000000@c.us", "order 42", "order  order 42,42"},
+		{"text does not match", "10000000@c.us", "order #42", "personal  "},
+		{"group chat", group, "Hello", "group  "},
+		{"personal chat", "10000000@c.us", "Hello", "personal  "},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := len(server.Requests())
+			idMessage := instance.ReceiveText(tt.chatId, "20000000@c.us", tt.text)
+			if err := router.HandleNotification(context.Background(), lastNotification(t, instance)); err != nil {
+				t.Fatal(err)
+			}
+
+			replies := sentMessages(t, server, sent)
+			if len(replies) != 1 {
+				t.Fatalf("%d replies sent, want 1", len(replies))
+			}
+			if got := replies[0]; got.Message != tt.want || got.ChatId.String() != tt.chatId || got.QuotedMessageId != idMessage {
+				t.Errorf("replied %q to %s quoting %q, want %q to %s quoting %q",
+					got.Message, got.ChatId, got.QuotedMessageId, tt.want, tt.chatId, idMessage)
+			}
+		})
+	}
+}
+
+func TestRouterIgnoresUnmatched(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+
+	router := notifications.NewRouter(instance.GreenAPI().Sending())
+	router.OnCommand("start", func(c *notifications.Context) error {
+		t.Error("the route of another command is called")
+		return nil
+	})
+
+	instance.ReceiveText("10000000@c.us", "", "Hello")
+	if err := router.HandleNotification(context.Background(), lastNotification(t, instance)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContextReply(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+	GreenAPI := instance.GreenAPI()
+
+	router := notifications.NewRouter(GreenAPI.Sending())
+	router.OnMessage(func(c *notifications.Context) error {
+		if _, err := c.Reply("quoted"); err != nil {
+			return err
+		}
+		if _, err := c.Reply("quoting another", greenapi.OptionalQuotedMessageId("BAE5000000000001")); err != nil {
+			return err
+		}
+		_, err := c.Send("not quoted")
+		return err
+	})
+
+	idMessage := instance.ReceiveText("10000000@c.us", "", "Hello")
+	if err := router.HandleNotification(context.Background(), lastNotification(t, instance)); err != nil {
+		t.Fatal(err)
+	}
+
+	var quoted []string
+	for _, m := range sentMessages(t, server, 0) {
+		quoted = append(quoted, m.QuotedMessageId)
+	}
+	if want := []string{idMessage, "BAE5000000000001", ""}; !slices.Equal(quoted, want) {
+		t.Errorf("quoted %q, want %q", quoted, want)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	router := notifications.NewRouter(greenapi.SendingCategory{})
+	router.Use(notifications.Recovery())
+	router.OnMessage(func(c *notifications.Context) error {
+		panic("broken handler")
+	})
+
+	err := router.HandleNotification(context.Background(), textNotification("10000000@c.us"))
+	if err == nil || !strings.Contains(err.Error(), "panic: broken handler") {
+		t.Errorf("got %v, want the panic as an error", err)
+	}
+}
+
+func TestRateLimitPerChat(t *testing.T) {
+	router := notifications.NewRouter(greenapi.SendingCategory{})
+	router.Use(notifications.RateLimitPerChat(2, 100*time.Millisecond))
+
+	handled := make(map[string]int)
+	router.OnMessage(func(c *notifications.Context) error {
+		handled[c.ChatId()]++
+		return nil
+	})
+
+	handle := func(chatId string, times int) {
+		for range times {
+			if err := router.HandleNotification(context.Background(), textNotification(chatId)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	handle("10000000@c.us", 3)
+	handle("20000000@c.us", 1)
+	if handled["10000000@c.us"] != 2 || handled["20000000@c.us"] != 1 {
+		t.Errorf("handled %v, want 2 of the first chat and 1 of the second", handled)
+	}
+
+	// A new period lets the chat through again.
+	time.Sleep(150 * time.Millisecond)
+	handle("10000000@c.us", 1)
+	if handled["10000000@c.us"] != 3 {
+		t.Errorf("handled %d of the first chat after the period, want 3", handled["10000000@c.us"])
+	}
+}
+
+// sentMessages returns the sendMessage requests received by the server after the first skip requests.
+func sentMessages(t *testing.T, server *greenapitest.Server, skip int) []greenapi.RequestSendMessage {
+	t.Helper()
+
+	var messages []greenapi.RequestSendMessage
+	for _, r := range server.Requests()[skip:] {
+		if r.Method != "sendMessage" {
+			continue
+		}
+		var m greenapi.RequestSendMessage
+		if err := json.Unmarshal(r.Body, &m); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, m)
+	}
+	return messages
+}
@@ -0,0 +1,172 @@
+package notifications
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	maxWebhookBodySize = 10 << 20
+	shutdownTimeout    = 10 * time.Second
+)
+
+// Receiver is implemented by Consumer and WebhookServer, so that the same
+// handlers can receive notifications by polling or by webhook.
+type Receiver interface {
+	Handle(typeWebhook string, h Handler)
+	HandleFunc(typeWebhook string, f func(ctx context.Context, n *Notification) error)
+	HandleDefault(h Handler)
+	Run(ctx context.Context) error
+}
+
+var (
+	_ Receiver = (*Consumer)(nil)
+	_ Receiver = (*WebhookServer)(nil)
+)
+
+// WebhookHandler is an http.Handler for the notifications sent to webhookUrl.
+// It responds with:
+//
+//	200 if the notification was handled or, with WithDedupe, already handled before
+//	401 if the Authorization header does not match webhookUrlToken
+//	400 if the payload cannot be decoded
+//	500 if the handler returned an error, so that the notification is sent again
+//
+// https://green-api.com/v3/docs/api/receiving/technology-webhook-endpoint/
+type WebhookHandler struct {
+	Mux
+
+	token  string
+	config *config
+}
+
+// NewWebhookHandler creates a handler that accepts notifications authorized with
+// webhookUrlToken, as set with OptionalWebhookUrlToken in SetSettings.
+// Pass an empty token to accept notifications without the Authorization header.
+//
+// Add optional arguments by passing these functions:
+//
+//	WithErrorHandler(f func(error)) <- Function called with every error of decoding or handling a notification.
+//	WithDedupe(store DedupeStore) <- Store of handled notifications, so that a resent webhook is acknowledged without handling.
+func NewWebhookHandler(webhookUrlToken string, options ...Option) (*WebhookHandler, error) {
+	c, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookHandler{
+		token:  webhookUrlToken,
+		config: c,
+	}, nil
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.authorized(r) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	body, err := Decode(data)
+	if err != nil {
+		h.config.ErrorHandler(fmt.Errorf("decoding webhook: %w", err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	n := &Notification{Body: body}
+	if err := h.config.dispatch(r.Context(), &h.Mux, n); err != nil {
+		h.config.ErrorHandler(fmt.Errorf("handling webhook %s: %w", body.WebhookType(), err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *WebhookHandler) authorized(r *http.Request) bool {
+	if h.token == "" {
+		return true
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+// WebhookServer listens on an address and serves a WebhookHandler on every path.
+type WebhookServer struct {
+	*WebhookHandler
+
+	Addr string
+}
+
+// NewWebhookServer creates a server for the notifications sent to webhookUrl.
+// It accepts the same optional arguments as NewWebhookHandler.
+func NewWebhookServer(addr, webhookUrlToken string, options ...Option) (*WebhookServer, error) {
+	h, err := NewWebhookHandler(webhookUrlToken, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookServer{
+		WebhookHandler: h,
+		Addr:           addr,
+	}, nil
+}
+
+// Run serves notifications until ctx is done, then waits for the running handlers
+// to finish. It returns nil after a clean stop.
+func (s *WebhookServer) Run(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.WebhookHandler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package notifications_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/green-api/max-api-client-golang/notifications"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	const payload = `{"typeWebhook":"incomingMessageReceived","idMessage":"BAE5000000000001",` +
+		`"senderData":{"chatId":"10000000@c.us","sender":"10000000@c.us"},` +
+		`"messageData":{"typeMessage":"textMessage","textMessageData":{"textMessage":"Hello"}}}`
+
+	for _, tt := range []struct {
+		name          string
+		method        string
+		authorization string
+		body          string
+		handlerErr    error
+		wantStatus    int
+		wantHandled   bool
+	}{
+		{"handled", http.MethodPost, "Bearer secret", payload, nil, http.StatusOK, true},
+		{"handler failed", http.MethodPost, "Bearer secret", payload, errors.New("handler failed"), http.StatusInternalServerError, true},
+		{"wrong token", http.MethodPost, "Bearer other", payload, nil, http.StatusUnauthorized, false},
+		{"no token", http.MethodPost, "", payload, nil, http.StatusUnauthorized, false},
+		{"not bearer", http.MethodPost, "secret", payload, nil, http.StatusUnauthorized, false},
+		{"invalid payload", http.MethodPost, "Bearer secret", `{"typeWebhook":`, nil, http.StatusBadRequest, false},
+		{"too large", http.MethodPost, "Bearer secret", strings.Repeat(" ", 10<<20+1), nil, http.StatusRequestEntityTooLarge, false},
+		{"get", http.MethodGet, "Bearer secret", "", nil, http.StatusMethodNotAllowed, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs []error
+			h, err := notifications.NewWebhookHandler("secret", notifications.WithErrorHandler(func(err error) {
+				errs = append(errs, err)
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var handled string
+			h.HandleFunc("incomingMessageReceived", func(ctx context.Context, n *notifications.Notification) error {
+				handled = n.Body.(notifications.MessageNotification).Message().MessageData.Text()
+				return tt.handlerErr
+			})
+
+			r := httptest.NewRequest(tt.method, "/webhook", strings.NewReader(tt.body))
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := handled == "Hello"; got != tt.wantHandled {
+				t.Errorf("handled %q, want handled: %v", handled, tt.wantHandled)
+			}
+			if tt.handlerErr != nil && (len(errs) != 1 || !errors.Is(errs[0], tt.handlerErr)) {
+				t.Errorf("errors %v, want the error of the handler", errs)
+			}
+		})
+	}
+}
+
+func TestWithErrorHandlerRejectsNil(t *testing.T) {
+	if _, err := notifications.NewWebhookHandler("", notifications.WithErrorHandler(nil)); err == nil {
+		t.Error("NewWebhookHandler accepted a nil error handler")
+	}
+}
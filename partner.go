@@ -1,6 +1,7 @@
 package greenapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -26,15 +27,45 @@ func OptionalName(name string) CreateInstanceOption {
 
 // ------------------------------------------------------------------ GetInstances
 
+type PartnerInstance struct {
+	IdInstance       int64  `json:"idInstance"`
+	Name             string `json:"name"`
+	TypeInstance     string `json:"typeInstance"`
+	TypeAccount      string `json:"typeAccount"`
+	PartnerUserUiid  string `json:"partnerUserUiid"`
+	TimeCreated      string `json:"timeCreated"`
+	TimeDeleted      string `json:"timeDeleted"`
+	ApiTokenInstance string `json:"apiTokenInstance"`
+	Deleted          bool   `json:"deleted"`
+	Tariff           string `json:"tariff"`
+	IsFree           bool   `json:"isFree"`
+	IsPartner        bool   `json:"isPartner"`
+	ExpirationDate   string `json:"expirationDate"`
+	IsExpired        bool   `json:"isExpired"`
+}
+
+type ResponseGetInstances []PartnerInstance
+
 // Getting all the account instances created by the partner.
 //
 // https://green-api.com/v3/docs/partners/getInstances/
 func (c PartnerCategory) GetInstances() (*APIResponse, error) {
-	return c.GreenAPIPartner.PartnerRequest("GET", "getInstances", nil)
+	return c.GetInstancesCtx(context.Background())
+}
+
+// GetInstancesCtx is like GetInstances but uses ctx to cancel or time out the request.
+func (c PartnerCategory) GetInstancesCtx(ctx context.Context) (*APIResponse, error) {
+	return c.GreenAPIPartner.PartnerRequest("GET", "getInstances", nil, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ CreateInstance
 
+type ResponseCreateInstance struct {
+	IdInstance       int64  `json:"idInstance"`
+	ApiTokenInstance string `json:"apiTokenInstance"`
+	TypeInstance     string `json:"typeInstance"`
+}
+
 // Creating an instance.
 //
 // https://green-api.com/v3/docs/partners/createInstance/
@@ -54,6 +85,11 @@ func (c PartnerCategory) GetInstances() (*APIResponse, error) {
 //	OptionalIncomingWebhook(incomingWebhook bool) <- Get notifications about incoming messages and files.
 
 func (c PartnerCategory) CreateInstance(options ...any) (*APIResponse, error) {
+	return c.CreateInstanceCtx(context.Background(), options...)
+}
+
+// CreateInstanceCtx is like CreateInstance but uses ctx to cancel or time out the request.
+func (c PartnerCategory) CreateInstanceCtx(ctx context.Context, options ...any) (*APIResponse, error) {
 	rCreateInstance := &RequestCreateInstance{}
 
 	for _, o := range options {
@@ -78,7 +114,7 @@ func (c PartnerCategory) CreateInstance(options ...any) (*APIResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	return c.GreenAPIPartner.PartnerRequest("POST", "createInstance", jsonData)
+	return c.GreenAPIPartner.PartnerRequest("POST", "createInstance", jsonData, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ DeleteInstanceAccount
@@ -87,10 +123,20 @@ type RequestDeleteInstanceAccount struct {
 	IdInstance uint `json:"idInstance"`
 }
 
+type ResponseDeleteInstanceAccount struct {
+	Code        int    `json:"code"`
+	Description string `json:"description,omitempty"`
+}
+
 // Deleting an instance.
 //
 // https://green-api.com/v3/docs/partners/deleteInstanceAccount/
 func (c PartnerCategory) DeleteInstanceAccount(idInstance uint) (*APIResponse, error) {
+	return c.DeleteInstanceAccountCtx(context.Background(), idInstance)
+}
+
+// DeleteInstanceAccountCtx is like DeleteInstanceAccount but uses ctx to cancel or time out the request.
+func (c PartnerCategory) DeleteInstanceAccountCtx(ctx context.Context, idInstance uint) (*APIResponse, error) {
 	r := &RequestDeleteInstanceAccount{
 		IdInstance: idInstance,
 	}
@@ -100,5 +146,5 @@ func (c PartnerCategory) DeleteInstanceAccount(idInstance uint) (*APIResponse, e
 		return nil, err
 	}
 
-	return c.GreenAPIPartner.PartnerRequest("POST", "deleteInstanceAccount", jsonData)
+	return c.GreenAPIPartner.PartnerRequest("POST", "deleteInstanceAccount", jsonData, WithContext(ctx))
 }
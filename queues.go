@@ -1,23 +1,51 @@
 package greenapi
 
+import (
+	"context"
+	"encoding/json"
+)
+
 type QueuesCategory struct {
 	GreenAPI GreenAPIInterface
 }
 
 // ------------------------------------------------------------------ ShowMessagesQueue
 
+type QueueMessage struct {
+	MessageID   string          `json:"messageID"`
+	MessagesIDs []string        `json:"messagesIDs,omitempty"`
+	Type        string          `json:"type"`
+	Body        json.RawMessage `json:"body"`
+}
+
+type ResponseShowMessagesQueue []QueueMessage
+
 // Getting a list of messages in the queue to be sent.
 //
 // https://green-api.com/v3/docs/api/queues/ShowMessagesQueue/
 func (c QueuesCategory) ShowMessagesQueue() (*APIResponse, error) {
-	return c.GreenAPI.Request("GET", "showMessagesQueue", nil)
+	return c.ShowMessagesQueueCtx(context.Background())
+}
+
+// ShowMessagesQueueCtx is like ShowMessagesQueue but uses ctx to cancel or time out the request.
+func (c QueuesCategory) ShowMessagesQueueCtx(ctx context.Context) (*APIResponse, error) {
+	return c.GreenAPI.Request("GET", "showMessagesQueue", nil, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ ClearMessagesQueue
 
+type ResponseClearMessagesQueue struct {
+	IsCleared bool `json:"isCleared"`
+}
+
 // Clearing the queue of messages to be sent.
 //
 // https://green-api.com/v3/docs/api/queues/ClearMessagesQueue/
 func (c QueuesCategory) ClearMessagesQueue() (*APIResponse, error) {
-	return c.GreenAPI.Request("GET", "clearMessagesQueue", nil)
+	return c.ClearMessagesQueueCtx(context.Background())
+}
+
+// ClearMessagesQueueCtx is like ClearMessagesQueue but uses ctx to cancel or time out the request.
+func (c QueuesCategory) ClearMessagesQueueCtx(ctx context.Context) (*APIResponse, error) {
+	return c.GreenAPI.Request("GET", "clearMessagesQueue", nil, WithContext(ctx))
 }
@@ -0,0 +1,282 @@
+package greenapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MethodGroup is a group of API methods limited together, named after the
+// category of the methods.
+type MethodGroup string
+
+const (
+	GroupAccount   MethodGroup = "account"
+	GroupSending   MethodGroup = "sending"
+	GroupReceiving MethodGroup = "receiving"
+	GroupGroups    MethodGroup = "groups"
+	GroupJournals  MethodGroup = "journals"
+	GroupQueues    MethodGroup = "queues"
+	GroupReadMark  MethodGroup = "readMark"
+	GroupService   MethodGroup = "service"
+	GroupPartner   MethodGroup = "partner"
+)
+
+var methodGroups = map[string]MethodGroup{
+	"getSettings":           GroupAccount,
+	"setSettings":           GroupAccount,
+	"getStateInstance":      GroupAccount,
+	"getStatusInstance":     GroupAccount,
+	"reboot":                GroupAccount,
+	"logout":                GroupAccount,
+	"startAuthorization":    GroupAccount,
+	"sendAuthorizationCode": GroupAccount,
+	"setProfilePicture":     GroupAccount,
+	"getAccountSettings":    GroupAccount,
+
+	"sendMessage":      GroupSending,
+	"sendFileByUpload": GroupSending,
+	"sendFileByUrl":    GroupSending,
+	"uploadFile":       GroupSending,
+
+	"receiveNotification": GroupReceiving,
+	"deleteNotification":  GroupReceiving,
+	"downloadFile":        GroupReceiving,
+
+	"createGroup":            GroupGroups,
+	"updateGroupName":        GroupGroups,
+	"getGroupData":           GroupGroups,
+	"addGroupParticipant":    GroupGroups,
+	"removeGroupParticipant": GroupGroups,
+	"setGroupAdmin":          GroupGroups,
+	"removeAdmin":            GroupGroups,
+	"setGroupPicture":        GroupGroups,
+	"leaveGroup":             GroupGroups,
+
+	"getChatHistory":       GroupJournals,
+	"getMessage":           GroupJournals,
+	"lastIncomingMessages": GroupJournals,
+	"lastOutgoingMessages": GroupJournals,
+
+	"showMessagesQueue":  GroupQueues,
+	"clearMessagesQueue": GroupQueues,
+
+	"readChat": GroupReadMark,
+
+	"CheckAccount":   GroupService,
+	"getAvatar":      GroupService,
+	"getContacts":    GroupService,
+	"getContactInfo": GroupService,
+
+	"getInstances":          GroupPartner,
+	"createInstance":        GroupPartner,
+	"deleteInstanceAccount": GroupPartner,
+}
+
+// MethodGroupOf returns the group of the API method, or an empty group for unknown methods.
+func MethodGroupOf(APIMethod string) MethodGroup {
+	return methodGroups[APIMethod]
+}
+
+// Limit is a token bucket: requests are sent once per Every on average,
+// and up to Burst requests at once after a pause.
+type Limit struct {
+	Every time.Duration
+	Burst int
+}
+
+func (l Limit) validate() error {
+	if l.Every <= 0 {
+		return fmt.Errorf("limit interval must be positive, got %v", l.Every)
+	}
+	if l.Burst < 1 {
+		return fmt.Errorf("limit burst must be at least 1, got %v", l.Burst)
+	}
+	return nil
+}
+
+// Limit all requests. Requests wait for their turn, or until the context of the request is done.
+func WithRateLimit(limit Limit) ClientOption {
+	return func(c *clientConfig) error {
+		if err := limit.validate(); err != nil {
+			return err
+		}
+		c.RateLimit = &limit
+		return nil
+	}
+}
+
+// Limit requests of the method group, in addition to the limit of all requests.
+func WithGroupRateLimit(group MethodGroup, limit Limit) ClientOption {
+	return func(c *clientConfig) error {
+		if err := limit.validate(); err != nil {
+			return err
+		}
+		if c.GroupRateLimits == nil {
+			c.GroupRateLimits = make(map[MethodGroup]Limit)
+		}
+		c.GroupRateLimits[group] = limit
+		return nil
+	}
+}
+
+// SetGroupRateLimit changes the limit of the method group while the object is in use.
+// It requires an object created with NewGreenAPI, whose HTTP client is not shared.
+func (a *GreenAPI) SetGroupRateLimit(group MethodGroup, limit Limit) error {
+	if a.client == nil {
+		return errors.New("rate limits require a GreenAPI created with NewGreenAPI")
+	}
+	if err := limit.validate(); err != nil {
+		return err
+	}
+	a.client.limiter.setGroup(group, limit)
+	return nil
+}
+
+// SyncRateLimit reads delaySendMessagesMilliseconds with GetSettings and limits
+// the sending methods to one request per delay, so that the messages queue of
+// the instance does not grow. With a zero delay the sending limit is removed.
+// The burst of a sending limit set before is kept.
+func (a *GreenAPI) SyncRateLimit(ctx context.Context) error {
+	if a.client == nil {
+		return errors.New("rate limits require a GreenAPI created with NewGreenAPI")
+	}
+
+	settings, err := Decode[ResponseGetSettings](a.Account().GetSettingsCtx(ctx))
+	if err != nil {
+		return err
+	}
+
+	if settings.DelaySendMessagesMilliseconds == 0 {
+		a.client.limiter.removeGroup(GroupSending)
+		return nil
+	}
+
+	burst := 1
+	if current, ok := a.client.limiter.group(GroupSending); ok {
+		burst = current.Burst
+	}
+	a.client.limiter.setGroup(GroupSending, Limit{
+		Every: time.Duration(settings.DelaySendMessagesMilliseconds) * time.Millisecond,
+		Burst: burst,
+	})
+	return nil
+}
+
+// rateLimiter holds the limit of all requests and the limits of method groups.
+type rateLimiter struct {
+	mu     sync.RWMutex
+	global *bucket
+	groups map[MethodGroup]*bucket
+}
+
+func newRateLimiter(global *Limit, groups map[MethodGroup]Limit) *rateLimiter {
+	l := &rateLimiter{groups: make(map[MethodGroup]*bucket)}
+	if global != nil {
+		l.global = newBucket(*global)
+	}
+	for group, limit := range groups {
+		l.groups[group] = newBucket(limit)
+	}
+	return l
+}
+
+func (l *rateLimiter) setGroup(group MethodGroup, limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.groups[group] = newBucket(limit)
+}
+
+func (l *rateLimiter) removeGroup(group MethodGroup) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.groups, group)
+}
+
+func (l *rateLimiter) group(group MethodGroup) (Limit, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	b, ok := l.groups[group]
+	if !ok {
+		return Limit{}, false
+	}
+	return b.limit, true
+}
+
+// wait blocks until the request to the API method may be sent. If ctx is done
+// while waiting for the group, the global token is returned as well.
+func (l *rateLimiter) wait(ctx context.Context, APIMethod string) error {
+	l.mu.RLock()
+	global, group := l.global, l.groups[MethodGroupOf(APIMethod)]
+	l.mu.RUnlock()
+
+	if global != nil {
+		if err := global.wait(ctx); err != nil {
+			return err
+		}
+	}
+	if group != nil {
+		if err := group.wait(ctx); err != nil {
+			if global != nil {
+				global.give()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+type bucket struct {
+	mu     sync.Mutex
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(limit Limit) *bucket {
+	return &bucket{
+		limit:  limit,
+		tokens: float64(limit.Burst),
+		last:   time.Now(),
+	}
+}
+
+// wait takes a token, waiting for it if the bucket is empty. A token taken by
+// a request whose context is done while waiting is returned to the bucket.
+func (b *bucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.tokens+float64(now.Sub(b.last))/float64(b.limit.Every), float64(b.limit.Burst))
+	b.last = now
+	b.tokens--
+	delay := time.Duration(-b.tokens * float64(b.limit.Every))
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.give()
+		return ctx.Err()
+	}
+}
+
+// give returns a token taken by a request that was not sent.
+func (b *bucket) give() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens++
+}
@@ -0,0 +1,82 @@
+package greenapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	limit := Limit{Every: 50 * time.Millisecond, Burst: 3}
+
+	for _, tt := range []struct {
+		name     string
+		requests int
+		minDelay time.Duration
+		maxDelay time.Duration
+	}{
+		{"burst", 3, 0, 20 * time.Millisecond},
+		{"after burst", 4, 40 * time.Millisecond, 90 * time.Millisecond},
+		{"twice the burst", 6, 140 * time.Millisecond, 200 * time.Millisecond},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBucket(limit)
+			start := time.Now()
+			for range tt.requests {
+				if err := b.wait(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if elapsed := time.Since(start); elapsed < tt.minDelay || elapsed > tt.maxDelay {
+				t.Errorf("%d requests took %v, want from %v to %v", tt.requests, elapsed, tt.minDelay, tt.maxDelay)
+			}
+		})
+	}
+}
+
+func TestBucketReturnsTokenOnCancel(t *testing.T) {
+	b := newBucket(Limit{Every: 100 * time.Millisecond, Burst: 1})
+	start := time.Now()
+	if err := b.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+
+	// The canceled request does not delay the next one by another interval.
+	if err := b.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("the next request waited until %v, want about 100ms", elapsed)
+	}
+}
+
+func TestRateLimiterReturnsGlobalTokenOnCancel(t *testing.T) {
+	global := Limit{Every: 200 * time.Millisecond, Burst: 1}
+	l := newRateLimiter(&global, map[MethodGroup]Limit{GroupSending: {Every: time.Hour, Burst: 1}})
+
+	if err := l.wait(context.Background(), "sendMessage"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Takes the next global token, then gives up waiting for the sending group.
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx, "sendMessage"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+
+	start := time.Now()
+	if err := l.wait(context.Background(), "getSettings"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("the next request waited %v for the global token of the canceled one", elapsed)
+	}
+}
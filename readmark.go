@@ -1,6 +1,9 @@
 package greenapi
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/json"
+)
 
 type ReadMarkCategory struct {
 	GreenAPI GreenAPIInterface
@@ -9,7 +12,7 @@ type ReadMarkCategory struct {
 // ------------------------------------------------------------------ ReadChat
 
 type RequestReadChat struct {
-	ChatId    string `json:"chatId"`
+	ChatId    ChatId `json:"chatId"`
 	IdMessage string `json:"idMessage,omitempty"`
 }
 
@@ -23,6 +26,10 @@ func OptionalIdMessage(idMessage string) ReadChatOption {
 	}
 }
 
+type ResponseReadChat struct {
+	SetRead bool `json:"setRead"`
+}
+
 // Marking messages in a chat as read.
 //
 // https://green-api.com/v3/docs/api/marks/ReadChat/
@@ -30,13 +37,18 @@ func OptionalIdMessage(idMessage string) ReadChatOption {
 // Add optional arguments by passing these functions:
 //  OptionalIdMessage(idMessage string) <- ID of the incoming message to be marked as read. If not specified, then all unread messages in the chat will be marked as read.
 func (c ReadMarkCategory) ReadChat(chatId string, options ...ReadChatOption) (*APIResponse, error) {
+	return c.ReadChatCtx(context.Background(), chatId, options...)
+}
+
+// ReadChatCtx is like ReadChat but uses ctx to cancel or time out the request.
+func (c ReadMarkCategory) ReadChatCtx(ctx context.Context, chatId string, options ...ReadChatOption) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestReadChat{
-		ChatId: chatId,
+		ChatId: ChatId(chatId),
 	}
 
 	for _, o := range options {
@@ -51,5 +63,5 @@ func (c ReadMarkCategory) ReadChat(chatId string, options ...ReadChatOption) (*A
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "readChat", jsonData)
+	return c.GreenAPI.Request("POST", "readChat", jsonData, WithContext(ctx))
 }
@@ -1,6 +1,7 @@
 package greenapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -25,6 +26,12 @@ func OptionalReceiveTimeout(seconds int) ReceiveNotificationOption {
 	}
 }
 
+// The response body is null if there are no notifications in the queue.
+type ResponseReceiveNotification struct {
+	ReceiptId int             `json:"receiptId"`
+	Body      json.RawMessage `json:"body"`
+}
+
 // Receiving one incoming notification from the notifications queue.
 //
 // https://green-api.com/v3/docs/api/receiving/technology-http-api/ReceiveNotification/
@@ -33,7 +40,11 @@ func OptionalReceiveTimeout(seconds int) ReceiveNotificationOption {
 //
 //	OptionalReceiveTimeout(seconds int) <- Notification waiting timeout, takes a value from 5 to 60 seconds (5 seconds by default)
 func (c ReceivingCategory) ReceiveNotification(options ...ReceiveNotificationOption) (*APIResponse, error) {
+	return c.ReceiveNotificationCtx(context.Background(), options...)
+}
 
+// ReceiveNotificationCtx is like ReceiveNotification but uses ctx to cancel or time out the request.
+func (c ReceivingCategory) ReceiveNotificationCtx(ctx context.Context, options ...ReceiveNotificationOption) (*APIResponse, error) {
 	r := &RequestReceiveNotification{}
 
 	for _, o := range options {
@@ -53,7 +64,7 @@ func (c ReceivingCategory) ReceiveNotification(options ...ReceiveNotificationOpt
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("GET", "receiveNotification", jsonData, WithGetParams(addUrl))
+	return c.GreenAPI.Request("GET", "receiveNotification", jsonData, WithGetParams(addUrl), WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ DeleteNotification
@@ -62,33 +73,51 @@ type RequestDeleteNotification struct {
 	ReceiptId int `json:"receiptId"`
 }
 
+type ResponseDeleteNotification struct {
+	Result bool `json:"result"`
+}
+
 // Deleting an incoming notification from the notification queue.
 //
 // https://green-api.com/v3/docs/api/receiving/technology-http-api/DeleteNotification/
 func (c ReceivingCategory) DeleteNotification(receiptId int) (*APIResponse, error) {
+	return c.DeleteNotificationCtx(context.Background(), receiptId)
+}
+
+// DeleteNotificationCtx is like DeleteNotification but uses ctx to cancel or time out the request.
+func (c ReceivingCategory) DeleteNotificationCtx(ctx context.Context, receiptId int) (*APIResponse, error) {
 	addUrl := fmt.Sprintf("/%v", receiptId)
 
-	return c.GreenAPI.Request("DELETE", "deleteNotification", nil, WithGetParams(addUrl))
+	return c.GreenAPI.Request("DELETE", "deleteNotification", nil, WithGetParams(addUrl), WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ DownloadFile
 
 type RequestDownloadFile struct {
-	ChatId    string `json:"chatId"`
+	ChatId    ChatId `json:"chatId"`
 	IdMessage string `json:"idMessage"`
 }
 
+type ResponseDownloadFile struct {
+	DownloadUrl string `json:"downloadUrl"`
+}
+
 // Downloading incoming and outgoing files from a chat.
 //
 // https://green-api.com/v3/docs/api/receiving/files/DownloadFile/
 func (c ReceivingCategory) DownloadFile(chatId, idMessage string) (*APIResponse, error) {
+	return c.DownloadFileCtx(context.Background(), chatId, idMessage)
+}
+
+// DownloadFileCtx is like DownloadFile but uses ctx to cancel or time out the request.
+func (c ReceivingCategory) DownloadFileCtx(ctx context.Context, chatId, idMessage string) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestDownloadFile{
-		ChatId:    chatId,
+		ChatId:    ChatId(chatId),
 		IdMessage: idMessage,
 	}
 
@@ -97,5 +126,5 @@ func (c ReceivingCategory) DownloadFile(chatId, idMessage string) (*APIResponse,
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "downloadFile", jsonData)
+	return c.GreenAPI.Request("POST", "downloadFile", jsonData, WithContext(ctx))
 }
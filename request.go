@@ -2,17 +2,15 @@ package greenapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
-	"net/textproto"
-	"os"
+	"net/http"
 	"path/filepath"
-	"strings"
-	"time"
+	"sort"
 
-	"github.com/gabriel-vasile/mimetype"
 	"github.com/valyala/fasthttp"
 )
 
@@ -22,6 +20,13 @@ type requestType struct {
 	SetMimetype mtype
 	Partner     bool
 	MediaHost   bool
+	Context     context.Context
+	BodyStream  io.Reader
+	BodySize    int64
+	// Headers added by the interceptors.
+	Header http.Header
+
+	IdempotencyGuard IdempotencyGuard
 }
 
 type requestOptions func(*requestType) error
@@ -59,115 +64,213 @@ func WithMediaHost(b bool) requestOptions {
 	}
 }
 
-func (a *GreenAPI) Request(HTTPMethod, APIMethod string, requestBody []byte, options ...requestOptions) (*APIResponse, error) {
-	r := &requestType{}
+// The context is used to cancel or time out the request, including long-polling
+// and file uploads. Without this option the request is not bounded.
+func WithContext(ctx context.Context) requestOptions {
+	return func(r *requestType) error {
+		if ctx == nil {
+			return fmt.Errorf("nil context")
+		}
+		r.Context = ctx
+		return nil
+	}
+}
+
+// The body is read from r while it is sent, instead of the requestBody argument.
+// size is the length of the body, or -1 to send it with chunked encoding.
+// A request with a body stream is never retried, because the stream cannot be read twice.
+func WithBodyStream(r io.Reader, size int64) requestOptions {
+	return func(rt *requestType) error {
+		if r == nil {
+			return fmt.Errorf("nil body stream")
+		}
+		rt.BodyStream = r
+		rt.BodySize = size
+		return nil
+	}
+}
+
+func newRequestType(options []requestOptions) (*requestType, error) {
+	r := &requestType{Context: context.Background()}
 	for _, o := range options {
 		err := o(r)
 		if err != nil {
 			return nil, err
 		}
 	}
-
-	return a.request(HTTPMethod, APIMethod, r.GetParams, r.SetMimetype, r.FormData, r.MediaHost, requestBody)
+	return r, nil
 }
 
-func (a *GreenAPIPartner) PartnerRequest(HTTPMethod, APIMethod string, requestBody []byte) (*APIResponse, error) {
-	client := &fasthttp.Client{}
-	client.Name = "green-api-go-client " + a.Email
-
-	req := fasthttp.AcquireRequest()
-	defer fasthttp.ReleaseRequest(req)
+func (a *GreenAPI) Request(HTTPMethod, APIMethod string, requestBody []byte, options ...requestOptions) (*APIResponse, error) {
+	r, err := newRequestType(options)
+	if err != nil {
+		return nil, err
+	}
 
-	req.SetRequestURI(fmt.Sprintf("https://api.green-api.com/v3/partner/%s/%s", APIMethod, a.PartnerToken))
+	return a.apiClient().intercept(newCall(r, HTTPMethod, APIMethod, requestBody), func(call *Call) (*APIResponse, error) {
+		call.apply(r)
+		return a.invoke(r, call.HTTPMethod, call.APIMethod, call.Body)
+	})
+}
 
-	req.Header.SetMethod(HTTPMethod)
-	req.Header.Set("Content-Type", "application/json")
+// invoke sends the request after the interceptors.
+func (a *GreenAPI) invoke(r *requestType, HTTPMethod, APIMethod string, requestBody []byte) (*APIResponse, error) {
+	client := a.apiClient()
 
-	if requestBody != nil {
-		req.SetBody(requestBody)
+	send := func() (*APIResponse, error) {
+		// Replayed requests do not reach the API, so they are not limited.
+		if !client.cassette.replaying() {
+			if err := client.limiter.wait(r.Context, APIMethod); err != nil {
+				return nil, err
+			}
+		}
+		if client.cassette != nil {
+			secrets := map[string]string{a.APITokenInstance: "{APITokenInstance}"}
+			return client.cassette.roundTrip(r, HTTPMethod, APIMethod, a.methodURL(r, APIMethod), requestBody, secrets, func() (*APIResponse, error) {
+				return a.request(r, HTTPMethod, APIMethod, requestBody)
+			})
+		}
+		return a.request(r, HTTPMethod, APIMethod, requestBody)
 	}
 
-	resp := fasthttp.AcquireResponse()
-	defer fasthttp.ReleaseResponse(resp)
+	if r.IdempotencyGuard == nil {
+		r.IdempotencyGuard = client.guards[APIMethod]
+	}
 
-	if err := client.Do(req, resp); err != nil {
-		return nil, fmt.Errorf("request error: %s", err)
+	var resp *APIResponse
+	var err error
+	if client.retry != nil && r.BodyStream == nil {
+		resp, err = client.retry.doWithRetry(r.Context, APIMethod, r, send)
+	} else {
+		resp, err = send()
 	}
 
-	return &APIResponse{
-		StatusCode:    resp.StatusCode(),
-		StatusMessage: resp.Header.StatusMessage(),
-		Body:          resp.Body(),
-		Timestamp:     time.Now(),
-	}, nil
+	return client.checkResponse(APIMethod, resp, err)
 }
 
-func MultipartRequest(method, url string, requestBody []byte) (*fasthttp.Request, error) {
-	buffer := &bytes.Buffer{}
-	writer := multipart.NewWriter(buffer)
-
-	var filePath string
-
-	var UnmarshaledBody map[string]interface{}
-
-	err := json.Unmarshal(requestBody, &UnmarshaledBody)
+func (a *GreenAPIPartner) PartnerRequest(HTTPMethod, APIMethod string, requestBody []byte, options ...requestOptions) (*APIResponse, error) {
+	r, err := newRequestType(options)
 	if err != nil {
 		return nil, err
 	}
 
-	if v, ok := UnmarshaledBody["file"]; ok {
-		filePath = v.(string)
-	} else {
-		return nil, fmt.Errorf("failed to retrieve FilePath from requestBody")
-	}
+	return a.apiClient().intercept(newCall(r, HTTPMethod, APIMethod, requestBody), func(call *Call) (*APIResponse, error) {
+		call.apply(r)
+		return a.invoke(r, call.HTTPMethod, call.APIMethod, call.Body)
+	})
+}
+
+// invoke sends the request after the interceptors.
+func (a *GreenAPIPartner) invoke(r *requestType, HTTPMethod, APIMethod string, requestBody []byte) (*APIResponse, error) {
+	client := a.apiClient()
 
-	for key, value := range UnmarshaledBody {
-		if key == "file" {
-			continue
+	send := func() (*APIResponse, error) {
+		if !client.cassette.replaying() {
+			if err := client.limiter.wait(r.Context, APIMethod); err != nil {
+				return nil, err
+			}
 		}
-		err := writer.WriteField(key, value.(string))
-		if err != nil {
-			return nil, err
+		if client.cassette != nil {
+			secrets := map[string]string{a.PartnerToken: "{PartnerToken}"}
+			return client.cassette.roundTrip(r, HTTPMethod, APIMethod, a.methodURL(APIMethod), requestBody, secrets, func() (*APIResponse, error) {
+				return a.partnerRequest(r, HTTPMethod, APIMethod, requestBody)
+			})
 		}
+		return a.partnerRequest(r, HTTPMethod, APIMethod, requestBody)
 	}
 
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
+	if r.IdempotencyGuard == nil {
+		r.IdempotencyGuard = client.guards[APIMethod]
 	}
-	defer file.Close()
 
-	//this is modified code of writer.CreateFormFile function
-	//the original function does not allow to set Content-Type of a particular field of Form-Data other than application/octet
-	h := make(textproto.MIMEHeader)
+	var resp *APIResponse
+	var err error
+	if client.retry != nil {
+		resp, err = client.retry.doWithRetry(r.Context, APIMethod, r, send)
+	} else {
+		resp, err = send()
+	}
 
-	var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+	return client.checkResponse(APIMethod, resp, err)
+}
 
-	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
-		quoteEscaper.Replace("file"), quoteEscaper.Replace(filepath.Base(filePath))))
+func (a *GreenAPIPartner) partnerRequest(r *requestType, HTTPMethod, APIMethod string, requestBody []byte) (*APIResponse, error) {
+	client := a.apiClient()
 
-	mtype, err := mimetype.DetectFile(filePath)
-	if err != nil {
-		return nil, err
+	userAgent := client.userAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent + " " + a.Email
 	}
-	h.Set("Content-Type", mtype.String())
 
-	part, err := writer.CreatePart(h)
-	if err != nil {
-		return nil, err
+	req := &HTTPRequest{
+		Method: HTTPMethod,
+		URL:    a.methodURL(APIMethod),
+		Header: http.Header{
+			"User-Agent":   {userAgent},
+			"Content-Type": {"application/json"},
+		},
 	}
 
-	_, err = io.Copy(part, file)
-	if err != nil {
-		return nil, err
+	for key, values := range r.Header {
+		req.Header[key] = values
+	}
+
+	if requestBody != nil {
+		req.Body = bytes.NewReader(requestBody)
+		req.ContentLength = int64(len(requestBody))
 	}
 
-	err = file.Close()
+	return client.do(r.Context, req)
+}
+
+func (a *GreenAPIPartner) methodURL(APIMethod string) string {
+	partnerURL := a.PartnerURL
+	if partnerURL == "" {
+		partnerURL = defaultPartnerURL
+	}
+	return fmt.Sprintf("%s/%s/%s", partnerURL, APIMethod, a.PartnerToken)
+}
+
+// do sends req with the transport of the client. A failure of the transport
+// is returned as a *requestError, so that it can be retried.
+func (c *apiClient) do(ctx context.Context, req *HTTPRequest) (*APIResponse, error) {
+	resp, err := c.transport.Do(ctx, req)
 	if err != nil {
-		return nil, err
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		return nil, &requestError{err: err}
 	}
+	return resp, nil
+}
+
+// requestError is a failure to deliver a request or to receive its response.
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string {
+	return fmt.Sprintf("request error: %s", e.err)
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
 
-	err = writer.Close()
+func isTransportError(err error) bool {
+	var target *requestError
+	return errors.As(err, &target)
+}
+
+// MultipartRequest builds a multipart/form-data request from a JSON object of form
+// fields, where the "file" field is the path of the file to upload. Strings are sent
+// as is, numbers and booleans as their JSON text, and other values are rejected.
+// The file is read while the request is sent and closed after it.
+func MultipartRequest(method, url string, requestBody []byte) (*fasthttp.Request, error) {
+	form, err := multipartForm(requestBody)
 	if err != nil {
 		return nil, err
 	}
@@ -178,81 +281,153 @@ func MultipartRequest(method, url string, requestBody []byte) (*fasthttp.Request
 
 	req.Header.SetMethod("POST")
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Content-Type", form.ContentType)
 
-	req.SetBody(buffer.Bytes())
+	// fasthttp closes a body stream that implements io.Closer once it is read.
+	req.SetBodyStream(form, int(form.Length))
 
 	return req, nil
 }
 
-func (a *GreenAPI) request(HTTPMethod, APIMethod, GetParams string, SetMimetype mtype, FormData, MediaHost bool, requestBody []byte) (*APIResponse, error) {
-	client := &fasthttp.Client{
-		// Dial: func(addr string) (net.Conn, error) {
-		//     return fasthttp.DialTimeout(addr, 10*time.Second)
-		// },
-		// ReadTimeout: time.Second * 10,
-		// WriteTimeout: time.Second * 10,
+// formBody is a multipart/form-data body that reads the file while it is sent.
+type formBody struct {
+	io.Reader
+	// Closes the file.
+	io.Closer
+	ContentType string
+	// Length of the body, -1 if unknown.
+	Length int64
+}
+
+// multipartForm returns the body of a MultipartRequest. The caller closes it.
+func multipartForm(requestBody []byte) (*formBody, error) {
+	var unmarshaledBody map[string]json.RawMessage
+
+	err := json.Unmarshal(requestBody, &unmarshaledBody)
+	if err != nil {
+		return nil, err
 	}
-	client.Name = "green-api-go-client"
 
-	req := fasthttp.AcquireRequest()
-	defer fasthttp.ReleaseRequest(req)
+	var filePath string
+	rawPath, ok := unmarshaledBody["file"]
+	if !ok {
+		return nil, fmt.Errorf("failed to retrieve FilePath from requestBody")
+	}
+	err = json.Unmarshal(rawPath, &filePath)
+	if err != nil {
+		return nil, fmt.Errorf("file must be a path string: %w", err)
+	}
+
+	keys := make([]string, 0, len(unmarshaledBody))
+	for key := range unmarshaledBody {
+		if key != "file" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var fields []formField
+	for _, key := range keys {
+		value, ok, err := jsonFormValue(unmarshaledBody[key])
+		if err != nil {
+			return nil, fmt.Errorf("form field %s: %w", key, err)
+		}
+		if ok {
+			fields = append(fields, formField{Name: key, Value: value})
+		}
+	}
+
+	file, size, err := openFile(filePath)
+	if err != nil {
+		return nil, err
+	}
 
-	req.SetRequestURI(fmt.Sprintf("%s/waInstance%s/%s/%s", a.APIURL, a.IDInstance, APIMethod, a.APITokenInstance))
+	contentType, body, length, err := multipartStream(fields, []filePart{{
+		Field:  "file",
+		Name:   filepath.Base(filePath),
+		Reader: file,
+		Size:   size,
+	}})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
 
-	req.Header.SetMethod(HTTPMethod)
-	req.Header.Set("Content-Type", "application/json")
+	return &formBody{Reader: body, Closer: file, ContentType: contentType, Length: length}, nil
+}
 
-	if MediaHost {
-		req.SetRequestURI(fmt.Sprintf("%s/waInstance%s/%s/%s", a.MediaURL, a.IDInstance, APIMethod, a.APITokenInstance))
+// jsonFormValue returns the form value of a JSON scalar. ok is false for null.
+func jsonFormValue(raw json.RawMessage) (value string, ok bool, err error) {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case len(raw) == 0 || string(raw) == "null":
+		return "", false, nil
+	case raw[0] == '"':
+		err := json.Unmarshal(raw, &value)
+		return value, err == nil, err
+	case raw[0] == '{' || raw[0] == '[':
+		return "", false, fmt.Errorf("unsupported JSON value %s", raw)
+	default:
+		return string(raw), true, nil
 	}
+}
+
+func (a *GreenAPI) request(r *requestType, HTTPMethod, APIMethod string, requestBody []byte) (*APIResponse, error) {
+	client := a.apiClient()
 
-	if GetParams != "" {
-		req.SetRequestURI(req.URI().String() + GetParams)
+	userAgent := client.userAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
 	}
 
-	if FormData {
-		req, err := MultipartRequest(APIMethod, req.URI().String(), requestBody)
+	req := &HTTPRequest{
+		Method: HTTPMethod,
+		URL:    a.methodURL(r, APIMethod),
+		Header: http.Header{
+			"User-Agent":   {userAgent},
+			"Content-Type": {"application/json"},
+		},
+	}
+
+	if r.FormData {
+		form, err := multipartForm(requestBody)
 		if err != nil {
 			return nil, err
 		}
-		defer fasthttp.ReleaseRequest(req)
+		defer form.Close()
 
-		resp := fasthttp.AcquireResponse()
-		defer fasthttp.ReleaseResponse(resp)
+		req.Method = "POST"
+		req.Header.Set("Content-Type", form.ContentType)
+		req.Body = form
+		req.ContentLength = form.Length
+	}
 
-		if err := client.Do(req, resp); err != nil {
-			return nil, fmt.Errorf("request error: %s", err)
+	if r.SetMimetype.Mimetype != "" {
+		req.Header.Set("Content-Type", r.SetMimetype.Mimetype)
+		if r.SetMimetype.FileName != "" {
+			req.Header.Set("GA-Filename", r.SetMimetype.FileName)
 		}
-
-		return &APIResponse{
-			StatusCode:    resp.StatusCode(),
-			StatusMessage: resp.Header.StatusMessage(),
-			Body:          resp.Body(),
-			Timestamp:     time.Now(),
-		}, nil
 	}
 
-	if SetMimetype.Mimetype != "" {
-		req.Header.SetContentType(SetMimetype.Mimetype)
-		req.Header.Set("GA-Filename", SetMimetype.FileName)
+	for key, values := range r.Header {
+		req.Header[key] = values
 	}
 
-	if requestBody != nil {
-		req.SetBody(requestBody)
+	if r.BodyStream != nil {
+		req.Body = r.BodyStream
+		req.ContentLength = r.BodySize
+	} else if requestBody != nil && req.Body == nil {
+		req.Body = bytes.NewReader(requestBody)
+		req.ContentLength = int64(len(requestBody))
 	}
 
-	resp := fasthttp.AcquireResponse()
-	defer fasthttp.ReleaseResponse(resp)
+	return client.do(r.Context, req)
+}
 
-	if err := client.Do(req, resp); err != nil {
-		return nil, fmt.Errorf("request error: %s", err)
+func (a *GreenAPI) methodURL(r *requestType, APIMethod string) string {
+	host := a.APIURL
+	if r.MediaHost {
+		host = a.MediaURL
 	}
-
-	return &APIResponse{
-		StatusCode:    resp.StatusCode(),
-		StatusMessage: resp.Header.StatusMessage(),
-		Body:          resp.Body(),
-		Timestamp:     time.Now(),
-	}, nil
+	return fmt.Sprintf("%s/waInstance%s/%s/%s%s", host, a.IDInstance, APIMethod, a.APITokenInstance, r.GetParams)
 }
@@ -0,0 +1,59 @@
+package greenapi_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/greenapitest"
+)
+
+func TestFormDataRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "photo.txt")
+	if err := os.WriteFile(path, []byte("Hello, world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		transport greenapi.Transport
+	}{
+		{"fasthttp", greenapi.NewFastHTTPTransport(nil)},
+		{"net/http", greenapi.NewHTTPTransport(nil)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greenapitest.NewServer()
+			defer server.Close()
+			instance := server.NewInstance()
+			GreenAPI := newGreenAPI(t, server, instance, greenapi.WithTransport(tt.transport))
+
+			jsonData, err := json.Marshal(greenapi.RequestSendFileByUpload{
+				ChatId:   "10000000@c.us",
+				FilePath: path,
+				FileName: "hello.txt",
+				Caption:  "Caption",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := GreenAPI.Request("POST", "sendFileByUpload", jsonData, greenapi.WithFormData(true), greenapi.WithMediaHost(true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != 200 {
+				t.Fatalf("status %d: %s", resp.StatusCode, resp.Body)
+			}
+
+			messages := instance.Messages("10000000@c.us")
+			if len(messages) != 1 {
+				t.Fatalf("%d messages sent, want 1", len(messages))
+			}
+			if m := messages[0]; m.FileName != "hello.txt" || m.Caption != "Caption" || m.MimeType != "text/plain; charset=utf-8" {
+				t.Errorf("sent %s %q of type %s, want hello.txt \"Caption\" of type text/plain", m.FileName, m.Caption, m.MimeType)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package greenapi
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryAttempts   = 3
+	defaultRetryMinBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff = 30 * time.Second
+)
+
+// RetryPolicy describes how failed requests are repeated.
+// Zero fields are replaced with defaults: 3 attempts, backoff from 500ms to 30s
+// and DefaultRetryableStatus.
+type RetryPolicy struct {
+	// Total number of attempts including the first one.
+	MaxAttempts int
+	// Backoff before the second attempt. It doubles with every next attempt.
+	MinBackoff time.Duration
+	// Upper bound of the backoff. A longer Retry-After header still wins.
+	MaxBackoff time.Duration
+	// Reports whether a response with the given status code should be retried.
+	RetryableStatus func(statusCode int) bool
+}
+
+// DefaultRetryableStatus retries 429 Too Many Requests and 5xx server errors.
+func DefaultRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// Retry failed requests according to the policy. Only idempotent API methods
+// (see IsIdempotentMethod) are retried unless WithIdempotencyGuard is passed to the request.
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(c *clientConfig) error {
+		if policy.MaxAttempts == 0 {
+			policy.MaxAttempts = defaultRetryAttempts
+		}
+		if policy.MinBackoff == 0 {
+			policy.MinBackoff = defaultRetryMinBackoff
+		}
+		if policy.MaxBackoff == 0 {
+			policy.MaxBackoff = defaultRetryMaxBackoff
+		}
+		if policy.RetryableStatus == nil {
+			policy.RetryableStatus = DefaultRetryableStatus
+		}
+		c.Retry = &policy
+		return nil
+	}
+}
+
+// IdempotencyGuard decides whether an unsafe request may be sent again after
+// the failed attempt described by resp and err, for example by checking
+// LastOutgoingMessages for a message that has already been sent.
+type IdempotencyGuard func(ctx context.Context, resp *APIResponse, err error) bool
+
+// Allow retrying a request to a non-idempotent API method when the guard agrees.
+// It is accepted by Request; the category methods use WithMethodIdempotencyGuard.
+func WithIdempotencyGuard(guard IdempotencyGuard) requestOptions {
+	return func(r *requestType) error {
+		r.IdempotencyGuard = guard
+		return nil
+	}
+}
+
+// Allow retrying requests to the non-idempotent API method, e.g. "sendMessage",
+// when the guard agrees. A guard passed to the request with WithIdempotencyGuard wins.
+func WithMethodIdempotencyGuard(APIMethod string, guard IdempotencyGuard) ClientOption {
+	return func(c *clientConfig) error {
+		if guard == nil {
+			return fmt.Errorf("nil idempotency guard for %s", APIMethod)
+		}
+		if c.IdempotencyGuards == nil {
+			c.IdempotencyGuards = make(map[string]IdempotencyGuard)
+		}
+		c.IdempotencyGuards[APIMethod] = guard
+		return nil
+	}
+}
+
+var idempotentMethods = map[string]bool{
+	"getSettings":          true,
+	"setSettings":          true,
+	"getStateInstance":     true,
+	"getStatusInstance":    true,
+	"getAccountSettings":   true,
+	"getChatHistory":       true,
+	"getMessage":           true,
+	"lastIncomingMessages": true,
+	"lastOutgoingMessages": true,
+	"getGroupData":         true,
+	"showMessagesQueue":    true,
+	"readChat":             true,
+	"receiveNotification":  true,
+	"deleteNotification":   true,
+	"downloadFile":         true,
+	"CheckAccount":         true,
+	"getAvatar":            true,
+	"getContacts":          true,
+	"getContactInfo":       true,
+	"getInstances":         true,
+}
+
+// IsIdempotentMethod reports whether repeating a call to the API method
+// cannot cause a side effect twice, e.g. getSettings but not sendMessage.
+func IsIdempotentMethod(APIMethod string) bool {
+	return idempotentMethods[APIMethod]
+}
+
+// doWithRetry calls send until it succeeds, the policy gives up or ctx is done.
+// When ctx is done during a backoff, the error of ctx is returned wrapped with
+// the failure of the last attempt, together with its response, if any.
+func (p *RetryPolicy) doWithRetry(ctx context.Context, APIMethod string, r *requestType, send func() (*APIResponse, error)) (*APIResponse, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := send()
+
+		if attempt >= p.MaxAttempts || !p.shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+
+		if !IsIdempotentMethod(APIMethod) && (r.IdempotencyGuard == nil || !r.IdempotencyGuard(ctx, resp, err)) {
+			return resp, err
+		}
+
+		delay := p.backoff(attempt)
+		if retryAfter, ok := parseRetryAfter(resp); ok && retryAfter > delay {
+			delay = retryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, lastFailure(ctx.Err(), resp, err)
+		case <-timer.C:
+		}
+	}
+}
+
+// lastFailure wraps ctxErr with the failed attempt it interrupted the retries of.
+func lastFailure(ctxErr error, resp *APIResponse, err error) error {
+	if err != nil {
+		return fmt.Errorf("%w, last attempt: %w", ctxErr, err)
+	}
+	return fmt.Errorf("%w, last attempt: status %d", ctxErr, resp.StatusCode)
+}
+
+func (p *RetryPolicy) shouldRetry(ctx context.Context, resp *APIResponse, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		// Only transport failures are retried, not validation or encoding errors.
+		return resp == nil && isTransportError(err)
+	}
+	return p.RetryableStatus(resp.StatusCode)
+}
+
+// backoff returns an exponential delay with equal jitter for the given attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MinBackoff << (attempt - 1)
+	if d > p.MaxBackoff || d <= 0 {
+		d = p.MaxBackoff
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+// parseRetryAfter reads the Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(resp *APIResponse) (time.Duration, bool) {
+	if resp == nil || resp.Header == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,130 @@
+package greenapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/greenapitest"
+)
+
+var fastRetry = greenapi.RetryPolicy{MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+func TestMethodIdempotencyGuard(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		guard    bool
+		wantCode int
+		wantSent int
+	}{
+		{"without guard", false, http.StatusServiceUnavailable, 1},
+		{"with guard", true, http.StatusOK, 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greenapitest.NewServer()
+			defer server.Close()
+			instance := server.NewInstance()
+
+			options := []greenapi.ClientOption{greenapi.WithRetry(fastRetry)}
+			if tt.guard {
+				options = append(options, greenapi.WithMethodIdempotencyGuard("sendMessage",
+					func(ctx context.Context, resp *greenapi.APIResponse, err error) bool {
+						return resp != nil && resp.StatusCode == http.StatusServiceUnavailable
+					}))
+			}
+			GreenAPI := newGreenAPI(t, server, instance, options...)
+
+			server.Inject("sendMessage", greenapitest.ServerError(http.StatusServiceUnavailable))
+
+			resp, err := GreenAPI.Sending().SendMessage("10000000", "Hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if got := len(server.Requests()); got != tt.wantSent {
+				t.Errorf("%d requests sent, want %d", got, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestRetryInjectedFaults(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		faults      []greenapitest.Fault
+		wantCode    int
+		wantSent    int
+		wantElapsed time.Duration
+	}{
+		{"no faults", nil, http.StatusOK, 1, 0},
+		{"server errors", []greenapitest.Fault{greenapitest.ServerError(http.StatusBadGateway).Times(2)}, http.StatusOK, 3, 0},
+		{"gives up", []greenapitest.Fault{greenapitest.ServerError(http.StatusServiceUnavailable).Always()}, http.StatusServiceUnavailable, 3, 0},
+		{"not retryable", []greenapitest.Fault{greenapitest.Respond(http.StatusBadRequest, "Bad Request")}, http.StatusBadRequest, 1, 0},
+		{"dropped connection", []greenapitest.Fault{greenapitest.DropConnection(0)}, http.StatusOK, 2, 0},
+		{"retry after", []greenapitest.Fault{greenapitest.RateLimited(time.Second)}, http.StatusOK, 2, time.Second},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greenapitest.NewServer()
+			defer server.Close()
+			instance := server.NewInstance()
+			GreenAPI := newGreenAPI(t, server, instance, greenapi.WithRetry(fastRetry))
+
+			server.Inject("getSettings", tt.faults...)
+
+			start := time.Now()
+			resp, err := GreenAPI.Account().GetSettings()
+			elapsed := time.Since(start)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if got := len(server.Requests()); got != tt.wantSent {
+				t.Errorf("%d requests sent, want %d", got, tt.wantSent)
+			}
+			if elapsed < tt.wantElapsed {
+				t.Errorf("retried after %v, want at least %v", elapsed, tt.wantElapsed)
+			}
+		})
+	}
+}
+
+func TestRetryStopsOnContextDone(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+	GreenAPI := newGreenAPI(t, server, instance, greenapi.WithRetry(greenapi.RetryPolicy{MaxAttempts: 5, MinBackoff: time.Second}))
+
+	server.Inject("getSettings", greenapitest.ServerError(http.StatusServiceUnavailable).Always())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	// The error of ctx is returned with the response of the last attempt when ctx is done during the backoff.
+	resp, err := GreenAPI.Account().GetSettingsCtx(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	if resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("response %v, want the response of the last attempt with status %d", resp, http.StatusServiceUnavailable)
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("%d requests sent, want 1", got)
+	}
+}
+
+func newGreenAPI(t *testing.T, server *greenapitest.Server, instance *greenapitest.Instance, options ...greenapi.ClientOption) *greenapi.GreenAPI {
+	t.Helper()
+
+	GreenAPI, err := greenapi.NewGreenAPI(server.URL(), server.MediaURL(), instance.IdInstance, instance.APIToken(), options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return GreenAPI
+}
@@ -1,11 +1,11 @@
 package greenapi
 
 import (
+	"context"
 	"encoding/json"
-	"os"
-	"path/filepath"
-
-	"github.com/gabriel-vasile/mimetype"
+	"fmt"
+	"io"
+	"strings"
 )
 
 type SendingCategory struct {
@@ -15,7 +15,7 @@ type SendingCategory struct {
 // ------------------------------------------------------------------ SendMessage
 
 type RequestSendMessage struct {
-	ChatId          string `json:"chatId"`
+	ChatId          ChatId `json:"chatId"`
 	Message         string `json:"message"`
 	QuotedMessageId string `json:"quotedMessageId,omitempty"`
 	LinkPreview     *bool  `json:"linkPreview,omitempty"`
@@ -39,6 +39,10 @@ func OptionalLinkPreview(linkPreview bool) SendMessageOption {
 	}
 }
 
+type ResponseSendMessage struct {
+	IdMessage string `json:"idMessage"`
+}
+
 // Sending a text message.
 //
 // https://green-api.com/v3/docs/api/sending/SendMessage/
@@ -48,6 +52,11 @@ func OptionalLinkPreview(linkPreview bool) SendMessageOption {
 //	OptionalQuotedMessageId(quotedMessageId string) <- Quoted message ID. If present, the message will be sent quoting the specified chat message.
 //	OptionalLinkPreview(linkPreview bool) <- The parameter includes displaying a preview and a description of the link. Enabled by default.
 func (c SendingCategory) SendMessage(chatId, message string, options ...SendMessageOption) (*APIResponse, error) {
+	return c.SendMessageCtx(context.Background(), chatId, message, options...)
+}
+
+// SendMessageCtx is like SendMessage but uses ctx to cancel or time out the request.
+func (c SendingCategory) SendMessageCtx(ctx context.Context, chatId, message string, options ...SendMessageOption) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
@@ -59,7 +68,7 @@ func (c SendingCategory) SendMessage(chatId, message string, options ...SendMess
 	}
 
 	r := &RequestSendMessage{
-		ChatId:  chatId,
+		ChatId:  ChatId(chatId),
 		Message: message,
 	}
 
@@ -75,17 +84,91 @@ func (c SendingCategory) SendMessage(chatId, message string, options ...SendMess
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "sendMessage", jsonData)
+	return c.GreenAPI.Request("POST", "sendMessage", jsonData, WithContext(ctx))
+}
+
+// ------------------------------------------------------------------ SendLongMessage
+
+// Sending a text message longer than the limit of 20000 characters as several messages.
+// The text is split with SplitMessage and the parts are sent one after another,
+// only the first part quotes the message of OptionalQuotedMessageId.
+//
+// An empty message or one of only whitespace is rejected without sending.
+// The responses of the sent parts are returned in order. Sending stops at the first
+// part that fails, which is returned as an error (an *APIError for a non-2xx response),
+// together with the responses of the parts sent before it.
+//
+// https://green-api.com/v3/docs/api/sending/SendMessage/
+//
+// Add optional arguments by passing these functions:
+//
+//	OptionalQuotedMessageId(quotedMessageId string) <- Quoted message ID. If present, the first part will be sent quoting the specified chat message.
+//	OptionalLinkPreview(linkPreview bool) <- The parameter includes displaying a preview and a description of the link. Enabled by default.
+func (c SendingCategory) SendLongMessage(chatId, message string, options ...SendMessageOption) ([]*APIResponse, error) {
+	return c.SendLongMessageCtx(context.Background(), chatId, message, options...)
+}
+
+// SendLongMessageCtx is like SendLongMessage but uses ctx to cancel or time out the requests.
+func (c SendingCategory) SendLongMessageCtx(ctx context.Context, chatId, message string, options ...SendMessageOption) ([]*APIResponse, error) {
+	err := ValidateChatId(chatId)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RequestSendMessage{
+		ChatId: ChatId(chatId),
+	}
+
+	for _, o := range options {
+		err := o(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.TrimSpace(message) == "" {
+		return nil, fmt.Errorf("message is empty")
+	}
+
+	parts := SplitMessage(message, 20000)
+	responses := make([]*APIResponse, 0, len(parts))
+
+	for i, part := range parts {
+		r.Message = part
+		if i > 0 {
+			r.QuotedMessageId = ""
+		}
+
+		jsonData, err := json.Marshal(r)
+		if err != nil {
+			return responses, err
+		}
+
+		response, err := c.GreenAPI.Request("POST", "sendMessage", jsonData, WithContext(ctx))
+		if err != nil {
+			return responses, err
+		}
+		responses = append(responses, response)
+
+		if apiErr := newAPIError("sendMessage", response); apiErr != nil {
+			return responses, apiErr
+		}
+	}
+
+	return responses, nil
 }
 
 // ------------------------------------------------------------------ SendFileByUpload
 
 type RequestSendFileByUpload struct {
-	ChatId          string `json:"chatId"`
-	File            string `json:"file"`
-	FileName        string `json:"fileName"`
-	Caption         string `json:"caption,omitempty"`
-	QuotedMessageId string `json:"quotedMessageId,omitempty"`
+	ChatId          ChatId `json:"chatId" form:"chatId"`
+	File            File   `json:"-" form:"file"`
+	FileName        string `json:"fileName" form:"fileName"`
+	Caption         string `json:"caption,omitempty" form:"caption,omitempty"`
+	QuotedMessageId string `json:"quotedMessageId,omitempty" form:"quotedMessageId,omitempty"`
+	// Deprecated: Use File. The path of the file for a request encoded with
+	// json.Marshal and sent with Request and WithFormData.
+	FilePath string `json:"file,omitempty" form:"-"`
 }
 
 type SendFileByUploadOption func(*RequestSendFileByUpload) error
@@ -110,6 +193,11 @@ func OptionalQuotedMessageIdSendUpload(quotedMessageId string) SendFileByUploadO
 	}
 }
 
+type ResponseSendFileByUpload struct {
+	IdMessage string `json:"idMessage"`
+	UrlFile   string `json:"urlFile"`
+}
+
 // Uploading and sending a file.
 //
 // https://green-api.com/v3/docs/api/sending/SendFileByUpload/
@@ -119,15 +207,52 @@ func OptionalQuotedMessageIdSendUpload(quotedMessageId string) SendFileByUploadO
 //	OptionalCaptionSendUpload(caption string) <- File caption. Caption added to video, images. The maximum field length is 20000 characters.
 //	OptionalQuotedMessageIdSendUpload(quotedMessageId string) <- If specified, the message will be sent quoting the specified chat message.
 func (c SendingCategory) SendFileByUpload(chatId, filePath, fileName string, options ...SendFileByUploadOption) (*APIResponse, error) {
+	return c.SendFileByUploadCtx(context.Background(), chatId, filePath, fileName, options...)
+}
+
+// SendFileByUploadCtx is like SendFileByUpload but uses ctx to cancel or time out the request.
+func (c SendingCategory) SendFileByUploadCtx(ctx context.Context, chatId, filePath, fileName string, options ...SendFileByUploadOption) (*APIResponse, error) {
+	file := FileFromPath(filePath)
+	file.Name = fileName
+
+	return c.SendFileByUploadFromCtx(ctx, chatId, file, options...)
+}
+
+// Uploading and sending a file read from r. The file is streamed without loading it
+// into memory. size is the length of the file, or -1 if it is unknown.
+//
+// https://green-api.com/v3/docs/api/sending/SendFileByUpload/
+//
+// Accepts the same optional arguments as SendFileByUpload.
+func (c SendingCategory) SendFileByUploadReader(chatId string, r io.Reader, fileName string, size int64, options ...SendFileByUploadOption) (*APIResponse, error) {
+	return c.SendFileByUploadReaderCtx(context.Background(), chatId, r, fileName, size, options...)
+}
+
+// SendFileByUploadReaderCtx is like SendFileByUploadReader but uses ctx to cancel or time out the request.
+func (c SendingCategory) SendFileByUploadReaderCtx(ctx context.Context, chatId string, r io.Reader, fileName string, size int64, options ...SendFileByUploadOption) (*APIResponse, error) {
+	return c.SendFileByUploadFromCtx(ctx, chatId, FileFromReader(fileName, r, size), options...)
+}
+
+// Uploading and sending a file from any source, see File. file.Name is sent as the file name.
+//
+// https://green-api.com/v3/docs/api/sending/SendFileByUpload/
+//
+// Accepts the same optional arguments as SendFileByUpload.
+func (c SendingCategory) SendFileByUploadFrom(chatId string, file File, options ...SendFileByUploadOption) (*APIResponse, error) {
+	return c.SendFileByUploadFromCtx(context.Background(), chatId, file, options...)
+}
+
+// SendFileByUploadFromCtx is like SendFileByUploadFrom but uses ctx to cancel or time out the request.
+func (c SendingCategory) SendFileByUploadFromCtx(ctx context.Context, chatId string, file File, options ...SendFileByUploadOption) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestSendFileByUpload{
-		ChatId:   chatId,
-		FileName: fileName,
-		File:     filePath,
+		ChatId:   ChatId(chatId),
+		File:     file,
+		FileName: file.Name,
 	}
 
 	for _, o := range options {
@@ -137,18 +262,13 @@ func (c SendingCategory) SendFileByUpload(chatId, filePath, fileName string, opt
 		}
 	}
 
-	jsonData, err := json.Marshal(r)
-	if err != nil {
-		return nil, err
-	}
-
-	return c.GreenAPI.Request("POST", "sendFileByUpload", jsonData, WithFormData(true), WithMediaHost(true))
+	return uploadMultipart(ctx, c.GreenAPI, "sendFileByUpload", r, WithMediaHost(true))
 }
 
 // ------------------------------------------------------------------ SendFileByUrl
 
 type RequestSendFileByUrl struct {
-	ChatId          string `json:"chatId"`
+	ChatId          ChatId `json:"chatId"`
 	UrlFile         string `json:"urlFile"`
 	FileName        string `json:"fileName"`
 	Caption         string `json:"caption,omitempty"`
@@ -177,6 +297,10 @@ func OptionalQuotedMessageIdSendUrl(quotedMessageId string) SendFileByUrlOption
 	}
 }
 
+type ResponseSendFileByUrl struct {
+	IdMessage string `json:"idMessage"`
+}
+
 // Sending a file by URL.
 //
 // https://green-api.com/v3/docs/api/sending/SendFileByUrl/
@@ -186,6 +310,11 @@ func OptionalQuotedMessageIdSendUrl(quotedMessageId string) SendFileByUrlOption
 //	OptionalCaptionSendUrl(caption string) <- File caption. Caption added to video, images. The maximum field length is 20000 characters.
 //	OptionalQuotedMessageIdSendUrl(quotedMessageId string) <- If specified, the message will be sent quoting the specified chat message.
 func (c SendingCategory) SendFileByUrl(chatId, urlFile, fileName string, options ...SendFileByUrlOption) (*APIResponse, error) {
+	return c.SendFileByUrlCtx(context.Background(), chatId, urlFile, fileName, options...)
+}
+
+// SendFileByUrlCtx is like SendFileByUrl but uses ctx to cancel or time out the request.
+func (c SendingCategory) SendFileByUrlCtx(ctx context.Context, chatId, urlFile, fileName string, options ...SendFileByUrlOption) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
@@ -197,7 +326,7 @@ func (c SendingCategory) SendFileByUrl(chatId, urlFile, fileName string, options
 	}
 
 	r := &RequestSendFileByUrl{
-		ChatId:   chatId,
+		ChatId:   ChatId(chatId),
 		UrlFile:  urlFile,
 		FileName: fileName,
 	}
@@ -214,7 +343,7 @@ func (c SendingCategory) SendFileByUrl(chatId, urlFile, fileName string, options
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "sendFileByUrl", jsonData)
+	return c.GreenAPI.Request("POST", "sendFileByUrl", jsonData, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ UploadFile
@@ -224,18 +353,57 @@ type RequestUploadFile struct {
 	FileName string `json:"fileName"`
 }
 
+type ResponseUploadFile struct {
+	UrlFile string `json:"urlFile"`
+}
+
 // Uploading a file to the cloud storage.
 //
 // https://green-api.com/v3/docs/api/sending/UploadFile/
 func (c SendingCategory) UploadFile(filePath string) (*APIResponse, error) {
+	return c.UploadFileCtx(context.Background(), filePath)
+}
+
+// UploadFileCtx is like UploadFile but uses ctx to cancel or time out the request.
+func (c SendingCategory) UploadFileCtx(ctx context.Context, filePath string) (*APIResponse, error) {
+	return c.UploadFileFromCtx(ctx, FileFromPath(filePath))
+}
+
+// Uploading a file read from r to the cloud storage. The file is streamed without
+// loading it into memory. size is the length of the file, or -1 if it is unknown.
+//
+// https://green-api.com/v3/docs/api/sending/UploadFile/
+func (c SendingCategory) UploadFileReader(r io.Reader, fileName string, size int64) (*APIResponse, error) {
+	return c.UploadFileReaderCtx(context.Background(), r, fileName, size)
+}
+
+// UploadFileReaderCtx is like UploadFileReader but uses ctx to cancel or time out the request.
+func (c SendingCategory) UploadFileReaderCtx(ctx context.Context, r io.Reader, fileName string, size int64) (*APIResponse, error) {
+	return c.UploadFileFromCtx(ctx, FileFromReader(fileName, r, size))
+}
+
+// Uploading a file from any source, see File, to the cloud storage.
+//
+// https://green-api.com/v3/docs/api/sending/UploadFile/
+func (c SendingCategory) UploadFileFrom(file File) (*APIResponse, error) {
+	return c.UploadFileFromCtx(context.Background(), file)
+}
+
+// UploadFileFromCtx is like UploadFileFrom but uses ctx to cancel or time out the request.
+func (c SendingCategory) UploadFileFromCtx(ctx context.Context, file File) (*APIResponse, error) {
+	r, size, err := file.reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
 
-	binary, err := os.ReadFile(filePath)
+	fileMimetype, body, err := DetectMimeType(r)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "uploadFile", binary, WithSetMimetype(mtype{
-		Mimetype: mimetype.Detect(binary).String(),
-		FileName: filepath.Base(filePath),
-	}), WithMediaHost(true))
+	return c.GreenAPI.Request("POST", "uploadFile", nil, WithSetMimetype(mtype{
+		Mimetype: fileMimetype,
+		FileName: file.Name,
+	}), WithBodyStream(body, size), WithMediaHost(true), WithContext(ctx))
 }
@@ -1,6 +1,13 @@
 package greenapi
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
 
 type ServiceCategory struct {
 	GreenAPI GreenAPIInterface
@@ -9,15 +16,30 @@ type ServiceCategory struct {
 // ------------------------------------------------------------------ CheckAccount
 
 type RequestCheckAccount struct {
-	PhoneNumber int `json:"phoneNumber"`
+	PhoneNumber PhoneNumber `json:"phoneNumber"`
+}
+
+type ResponseCheckAccount struct {
+	Exist  bool   `json:"exist"`
+	ChatId string `json:"chatId,omitempty"`
 }
 
 // Checking a MAX account availability on a phone number.
 //
 // https://green-api.com/v3/docs/api/service/CheckAccount/
 func (c ServiceCategory) CheckAccount(phoneNumber int) (*APIResponse, error) {
+	return c.CheckAccountCtx(context.Background(), phoneNumber)
+}
+
+// CheckAccountCtx is like CheckAccount but uses ctx to cancel or time out the request.
+func (c ServiceCategory) CheckAccountCtx(ctx context.Context, phoneNumber int) (*APIResponse, error) {
+	err := PhoneNumber(phoneNumber).Validate()
+	if err != nil {
+		return nil, err
+	}
+
 	r := &RequestCheckAccount{
-		PhoneNumber: phoneNumber,
+		PhoneNumber: PhoneNumber(phoneNumber),
 	}
 
 	jsonData, err := json.Marshal(r)
@@ -25,26 +47,295 @@ func (c ServiceCategory) CheckAccount(phoneNumber int) (*APIResponse, error) {
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "CheckAccount", jsonData)
+	return c.GreenAPI.Request("POST", "CheckAccount", jsonData, WithContext(ctx))
+}
+
+// ------------------------------------------------------------------ CheckAccounts
+
+type RequestCheckAccounts struct {
+	Workers   int
+	RateLimit *Limit
+	Cache     AccountCache
+}
+
+type CheckAccountsOption func(*RequestCheckAccounts) error
+
+// Number of requests sent at the same time, 4 by default.
+func OptionalWorkers(workers int) CheckAccountsOption {
+	return func(r *RequestCheckAccounts) error {
+		if workers < 1 {
+			return fmt.Errorf("number of workers must be positive, got %v", workers)
+		}
+		r.Workers = workers
+		return nil
+	}
+}
+
+// Limit of the CheckAccount requests of this call, in addition to the limits of the client.
+func OptionalCheckRateLimit(limit Limit) CheckAccountsOption {
+	return func(r *RequestCheckAccounts) error {
+		if err := limit.validate(); err != nil {
+			return err
+		}
+		r.RateLimit = &limit
+		return nil
+	}
+}
+
+// Cache of the results, so that numbers checked recently are not requested again.
+func OptionalAccountCache(cache AccountCache) CheckAccountsOption {
+	return func(r *RequestCheckAccounts) error {
+		r.Cache = cache
+		return nil
+	}
+}
+
+// CheckAccountResult is the result of checking one phone number.
+type CheckAccountResult struct {
+	PhoneNumber int
+	Exist       bool
+	ChatId      string
+	// Cached is true if the result was taken from the cache.
+	Cached bool
+	// Err is set if the number could not be checked.
+	Err error
+}
+
+// CheckAccountsError is returned by CheckAccounts when some numbers could not be
+// checked. It wraps the first error, e.g. an *APIError.
+type CheckAccountsError struct {
+	Failed int
+	Total  int
+	Err    error
+}
+
+func (e *CheckAccountsError) Error() string {
+	return fmt.Sprintf("%d of %d phone numbers not checked: %v", e.Failed, e.Total, e.Err)
+}
+
+func (e *CheckAccountsError) Unwrap() error {
+	return e.Err
+}
+
+// Checking MAX account availability on many phone numbers with a pool of workers.
+// The results are in the order of phoneNumbers. If some numbers could not be checked,
+// their results have Err set and a *CheckAccountsError is returned with all results.
+// After an error that affects every request, e.g. ErrQuotaExceeded or ErrUnauthorized,
+// the remaining numbers are not requested.
+//
+// https://green-api.com/v3/docs/api/service/CheckAccount/
+//
+// Add optional arguments by passing these functions:
+//
+//	OptionalWorkers(workers int) <- Number of requests sent at the same time, 4 by default.
+//	OptionalCheckRateLimit(limit Limit) <- Limit of the CheckAccount requests of this call.
+//	OptionalAccountCache(cache AccountCache) <- Cache of the results, e.g. NewMemoryAccountCache(24 * time.Hour).
+func (c ServiceCategory) CheckAccounts(ctx context.Context, phoneNumbers []int, options ...CheckAccountsOption) ([]CheckAccountResult, error) {
+	r := &RequestCheckAccounts{Workers: 4}
+	for _, o := range options {
+		err := o(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]CheckAccountResult, len(phoneNumbers))
+
+	// Every number is requested once, even if it is repeated.
+	positions := make(map[int][]int)
+	var unique []int
+	for i, phoneNumber := range phoneNumbers {
+		results[i].PhoneNumber = phoneNumber
+		if _, ok := positions[phoneNumber]; !ok {
+			unique = append(unique, phoneNumber)
+		}
+		positions[phoneNumber] = append(positions[phoneNumber], i)
+	}
+
+	var limit *bucket
+	if r.RateLimit != nil {
+		limit = newBucket(*r.RateLimit)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < min(r.Workers, len(unique)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for phoneNumber := range jobs {
+				result := c.checkAccount(ctx, phoneNumber, limit, r.Cache)
+				if isFatalCheckError(result.Err) {
+					cancel(result.Err)
+				}
+				for _, i := range positions[phoneNumber] {
+					results[i] = result
+				}
+			}
+		}()
+	}
+
+	for _, phoneNumber := range unique {
+		select {
+		case jobs <- phoneNumber:
+			continue
+		case <-ctx.Done():
+		}
+		for _, i := range positions[phoneNumber] {
+			results[i].Err = context.Cause(ctx)
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var checkErr *CheckAccountsError
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		if checkErr == nil {
+			checkErr = &CheckAccountsError{Total: len(results), Err: result.Err}
+		}
+		checkErr.Failed++
+	}
+	if checkErr != nil {
+		return results, checkErr
+	}
+	return results, nil
+}
+
+func (c ServiceCategory) checkAccount(ctx context.Context, phoneNumber int, limit *bucket, cache AccountCache) CheckAccountResult {
+	result := CheckAccountResult{PhoneNumber: phoneNumber}
+
+	if cache != nil {
+		if cached, ok := cache.Get(phoneNumber); ok {
+			result.Exist, result.ChatId, result.Cached = cached.Exist, cached.ChatId, true
+			return result
+		}
+	}
+
+	if err := context.Cause(ctx); err != nil {
+		result.Err = err
+		return result
+	}
+	if limit != nil {
+		if err := limit.wait(ctx); err != nil {
+			result.Err = context.Cause(ctx)
+			return result
+		}
+	}
+
+	resp, err := Decode[ResponseCheckAccount](c.CheckAccountCtx(ctx, phoneNumber))
+	if err != nil {
+		if cause := context.Cause(ctx); cause != nil {
+			err = cause
+		}
+		result.Err = err
+		return result
+	}
+
+	if cache != nil {
+		cache.Set(phoneNumber, *resp)
+	}
+	result.Exist, result.ChatId = resp.Exist, resp.ChatId
+	return result
+}
+
+// isFatalCheckError reports whether the error would repeat for every other number.
+func isFatalCheckError(err error) bool {
+	return errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrUnauthorized) ||
+		errors.Is(err, ErrForbidden) || errors.Is(err, ErrInstanceNotAuthorized)
+}
+
+// AccountCache stores the results of CheckAccount by phone number.
+type AccountCache interface {
+	Get(phoneNumber int) (ResponseCheckAccount, bool)
+	Set(phoneNumber int, result ResponseCheckAccount)
+}
+
+type cachedAccount struct {
+	result  ResponseCheckAccount
+	expires time.Time
+}
+
+// MemoryAccountCache keeps the results in memory for ttl.
+type MemoryAccountCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[int]cachedAccount
+	// size after the last removal of expired entries
+	pruned int
+}
+
+func NewMemoryAccountCache(ttl time.Duration) *MemoryAccountCache {
+	return &MemoryAccountCache{
+		ttl:     ttl,
+		entries: make(map[int]cachedAccount),
+	}
+}
+
+func (c *MemoryAccountCache) Get(phoneNumber int) (ResponseCheckAccount, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[phoneNumber]
+	if !ok {
+		return ResponseCheckAccount{}, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, phoneNumber)
+		return ResponseCheckAccount{}, false
+	}
+	return entry.result, true
+}
+
+func (c *MemoryAccountCache) Set(phoneNumber int, result ResponseCheckAccount) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.entries[phoneNumber] = cachedAccount{result: result, expires: now.Add(c.ttl)}
+
+	if len(c.entries) > 2*c.pruned+1000 {
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+		c.pruned = len(c.entries)
+	}
 }
 
 // ------------------------------------------------------------------ GetAvatar
 
 type RequestGetAvatar struct {
-	ChatId string `json:"chatId"`
+	ChatId ChatId `json:"chatId"`
+}
+
+type ResponseGetAvatar struct {
+	UrlAvatar string `json:"urlAvatar"`
+	Available bool   `json:"available"`
 }
 
 // Getting a user or a group chat avatar.
 //
 // https://green-api.com/v3/docs/api/service/GetAvatar/
 func (c ServiceCategory) GetAvatar(chatId string) (*APIResponse, error) {
+	return c.GetAvatarCtx(context.Background(), chatId)
+}
+
+// GetAvatarCtx is like GetAvatar but uses ctx to cancel or time out the request.
+func (c ServiceCategory) GetAvatarCtx(ctx context.Context, chatId string) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestGetAvatar{
-		ChatId: chatId,
+		ChatId: ChatId(chatId),
 	}
 
 	jsonData, err := json.Marshal(r)
@@ -52,35 +343,68 @@ func (c ServiceCategory) GetAvatar(chatId string) (*APIResponse, error) {
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "getAvatar", jsonData)
+	return c.GreenAPI.Request("POST", "getAvatar", jsonData, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ GetContacts
 
+type Contact struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	ContactName string `json:"contactName"`
+	Type        string `json:"type"`
+}
+
+type ResponseGetContacts []Contact
+
 // Getting a list of the current account contacts.
 //
 // https://green-api.com/v3/docs/api/service/GetContacts/
 func (c ServiceCategory) GetContacts() (*APIResponse, error) {
-	return c.GreenAPI.Request("GET", "getContacts", nil)
+	return c.GetContactsCtx(context.Background())
+}
+
+// GetContactsCtx is like GetContacts but uses ctx to cancel or time out the request.
+func (c ServiceCategory) GetContactsCtx(ctx context.Context) (*APIResponse, error) {
+	return c.GreenAPI.Request("GET", "getContacts", nil, WithContext(ctx))
 }
 
 // ------------------------------------------------------------------ GetContactInfo
 
 type RequestGetContactInfo struct {
-	ChatId string `json:"chatId"`
+	ChatId ChatId `json:"chatId"`
+}
+
+type ResponseGetContactInfo struct {
+	Avatar      string `json:"avatar"`
+	Name        string `json:"name"`
+	ContactName string `json:"contactName"`
+	Email       string `json:"email,omitempty"`
+	Category    string `json:"category,omitempty"`
+	Description string `json:"description,omitempty"`
+	ChatId      string `json:"chatId"`
+	LastSeen    string `json:"lastSeen,omitempty"`
+	IsArchive   bool   `json:"isArchive"`
+	IsMute      bool   `json:"isMute"`
+	IsBusiness  bool   `json:"isBusiness"`
 }
 
 // Getting information about a contact.
 //
 // https://green-api.com/v3/docs/api/service/GetContactInfo/
 func (c ServiceCategory) GetContactInfo(chatId string) (*APIResponse, error) {
+	return c.GetContactInfoCtx(context.Background(), chatId)
+}
+
+// GetContactInfoCtx is like GetContactInfo but uses ctx to cancel or time out the request.
+func (c ServiceCategory) GetContactInfoCtx(ctx context.Context, chatId string) (*APIResponse, error) {
 	err := ValidateChatId(chatId)
 	if err != nil {
 		return nil, err
 	}
 
 	r := &RequestGetContactInfo{
-		ChatId: chatId,
+		ChatId: ChatId(chatId),
 	}
 
 	jsonData, err := json.Marshal(r)
@@ -88,5 +412,5 @@ func (c ServiceCategory) GetContactInfo(chatId string) (*APIResponse, error) {
 		return nil, err
 	}
 
-	return c.GreenAPI.Request("POST", "getContactInfo", jsonData)
+	return c.GreenAPI.Request("POST", "getContactInfo", jsonData, WithContext(ctx))
 }
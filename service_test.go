@@ -0,0 +1,122 @@
+package greenapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/greenapitest"
+)
+
+func TestCheckAccounts(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+	instance.SetAccount(79990000002, false)
+	GreenAPI := newGreenAPI(t, server, instance)
+
+	cache := greenapi.NewMemoryAccountCache(time.Hour)
+	cache.Set(79990000003, greenapi.ResponseCheckAccount{Exist: true, ChatId: "79990000003@c.us"})
+
+	phoneNumbers := []int{79990000001, 79990000002, 79990000001, 79990000003, 79990000002}
+	for _, tt := range []struct {
+		name         string
+		want         []greenapi.CheckAccountResult
+		wantRequests int
+	}{
+		{
+			name: "duplicates and a cached number",
+			want: []greenapi.CheckAccountResult{
+				{PhoneNumber: 79990000001, Exist: true, ChatId: "79990000001@c.us"},
+				{PhoneNumber: 79990000002},
+				{PhoneNumber: 79990000001, Exist: true, ChatId: "79990000001@c.us"},
+				{PhoneNumber: 79990000003, Exist: true, ChatId: "79990000003@c.us", Cached: true},
+				{PhoneNumber: 79990000002},
+			},
+			wantRequests: 2,
+		},
+		{
+			name: "all cached",
+			want: []greenapi.CheckAccountResult{
+				{PhoneNumber: 79990000001, Exist: true, ChatId: "79990000001@c.us", Cached: true},
+				{PhoneNumber: 79990000002, Cached: true},
+				{PhoneNumber: 79990000001, Exist: true, ChatId: "79990000001@c.us", Cached: true},
+				{PhoneNumber: 79990000003, Exist: true, ChatId: "79990000003@c.us", Cached: true},
+				{PhoneNumber: 79990000002, Cached: true},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := len(server.Requests())
+			results, err := GreenAPI.Service().CheckAccounts(context.Background(), phoneNumbers, greenapi.OptionalAccountCache(cache))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.want {
+				if results[i] != want {
+					t.Errorf("result %d: %+v, want %+v", i, results[i], want)
+				}
+			}
+			if got := len(server.Requests()) - sent; got != tt.wantRequests {
+				t.Errorf("%d requests sent, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestCheckAccountsErrors(t *testing.T) {
+	phoneNumbers := []int{79990000001, 79990000002, 79990000003, 79990000004, 79990000005}
+
+	for _, tt := range []struct {
+		name         string
+		fault        greenapitest.Fault
+		wantErr      error
+		wantFailed   int
+		wantRequests int
+	}{
+		// A fatal error stops the check, even though the next requests would succeed.
+		{"quota exceeded", greenapitest.Respond(466, "Quota exceeded"), greenapi.ErrQuotaExceeded, 5, 1},
+		{"unauthorized", greenapitest.Respond(http.StatusUnauthorized, "Unauthorized"), greenapi.ErrUnauthorized, 5, 1},
+		{"server error", greenapitest.ServerError(http.StatusInternalServerError), greenapi.ErrServerError, 1, 5},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greenapitest.NewServer()
+			defer server.Close()
+			instance := server.NewInstance()
+			GreenAPI := newGreenAPI(t, server, instance)
+			server.Inject("CheckAccount", tt.fault)
+
+			results, err := GreenAPI.Service().CheckAccounts(context.Background(), phoneNumbers, greenapi.OptionalWorkers(1))
+
+			var checkErr *greenapi.CheckAccountsError
+			if !errors.As(err, &checkErr) {
+				t.Fatalf("got %v, want a *CheckAccountsError", err)
+			}
+			if checkErr.Failed != tt.wantFailed || checkErr.Total != len(phoneNumbers) {
+				t.Errorf("%d of %d failed, want %d of %d", checkErr.Failed, checkErr.Total, tt.wantFailed, len(phoneNumbers))
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+
+			failed := 0
+			for i, result := range results {
+				if result.PhoneNumber != phoneNumbers[i] {
+					t.Errorf("result %d is of %d, want %d", i, result.PhoneNumber, phoneNumbers[i])
+				}
+				if result.Err != nil {
+					failed++
+				}
+			}
+			if failed != tt.wantFailed {
+				t.Errorf("%d results with an error, want %d", failed, tt.wantFailed)
+			}
+			if got := len(server.Requests()); got != tt.wantRequests {
+				t.Errorf("%d requests sent, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package greenapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// HTTPRequest is a request to the API built by GreenAPI or GreenAPIPartner.
+type HTTPRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	// Body of the request, nil if there is none.
+	Body io.Reader
+	// Length of Body, or -1 to send it with chunked encoding.
+	ContentLength int64
+}
+
+// Transport sends the requests of GreenAPI and GreenAPIPartner. The default
+// transport uses fasthttp; NewHTTPTransport plugs in a net/http client with
+// its own RoundTripper, e.g. for instrumentation or a corporate proxy.
+//
+// Do returns the full response whatever its status code. An error is a failure
+// to deliver the request or to receive the response, and is retried by WithRetry
+// unless ctx is done.
+type Transport interface {
+	Do(ctx context.Context, req *HTTPRequest) (*APIResponse, error)
+}
+
+// Send all requests with the transport instead of the default fasthttp client.
+// WithDialTimeout, WithReadTimeout, WithWriteTimeout, WithMaxConnsPerHost,
+// WithTLSConfig and WithProxy only configure the default transport.
+//
+// Files are downloaded with the transport as well: a transport from
+// NewHTTPTransport streams them with its client, any other one gets a GET
+// request for the download URL, and its response holds the whole file in memory.
+func WithTransport(transport Transport) ClientOption {
+	return func(c *clientConfig) error {
+		if transport == nil {
+			return fmt.Errorf("nil transport")
+		}
+		c.Transport = transport
+		return nil
+	}
+}
+
+// ------------------------------------------------------------------ fasthttp
+
+type fastHTTPTransport struct {
+	client *fasthttp.Client
+}
+
+// NewFastHTTPTransport returns a transport that sends requests with the fasthttp client.
+func NewFastHTTPTransport(client *fasthttp.Client) Transport {
+	if client == nil {
+		client = &fasthttp.Client{}
+	}
+	return &fastHTTPTransport{client: client}
+}
+
+func (t *fastHTTPTransport) Do(ctx context.Context, r *HTTPRequest) (*APIResponse, error) {
+	req := fasthttp.AcquireRequest()
+
+	req.SetRequestURI(r.URL)
+	req.Header.SetMethod(r.Method)
+	for key, values := range r.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	switch body := r.Body.(type) {
+	case nil:
+	case *bytes.Reader:
+		data, err := io.ReadAll(body)
+		if err != nil {
+			fasthttp.ReleaseRequest(req)
+			return nil, err
+		}
+		req.SetBody(data)
+	default:
+		req.SetBodyStream(&contextReader{ctx: ctx, r: body}, int(r.ContentLength))
+	}
+
+	return t.do(ctx, req)
+}
+
+// do sends req and takes ownership of it. If ctx is done before the response
+// arrives, do returns ctx.Err() immediately and the request and response are
+// released in the background once the client gives them back.
+//
+// fasthttp cannot abort a request in flight, so the goroutine sending it keeps
+// running after do returns: a body stream fails on the next read, but the
+// connection stays busy until the server responds or closes it, or until
+// ReadTimeout or WriteTimeout (unlimited by default) runs out.
+func (t *fastHTTPTransport) do(ctx context.Context, req *fasthttp.Request) (*APIResponse, error) {
+	if err := ctx.Err(); err != nil {
+		fasthttp.ReleaseRequest(req)
+		return nil, err
+	}
+
+	resp := fasthttp.AcquireResponse()
+	release := func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(resp)
+	}
+
+	send := func() error {
+		if deadline, ok := ctx.Deadline(); ok {
+			return t.client.DoDeadline(req, resp, deadline)
+		}
+		return t.client.Do(req, resp)
+	}
+
+	var err error
+	if ctx.Done() == nil {
+		err = send()
+	} else {
+		done := make(chan error, 1)
+		go func() {
+			done <- send()
+		}()
+
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			go func() {
+				<-done
+				release()
+			}()
+			return nil, ctx.Err()
+		}
+	}
+	defer release()
+
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		// DoDeadline may give up a moment before the context timer fires. Waiting
+		// for it lets the caller see ctx.Err() along with the error.
+		if deadline, ok := ctx.Deadline(); ok && errors.Is(err, fasthttp.ErrTimeout) && !time.Now().Before(deadline) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	header := make(http.Header)
+	resp.Header.VisitAll(func(key, value []byte) {
+		header.Add(string(key), string(value))
+	})
+
+	return &APIResponse{
+		StatusCode:    resp.StatusCode(),
+		StatusMessage: append([]byte(nil), resp.Header.StatusMessage()...),
+		Body:          append([]byte(nil), resp.Body()...),
+		Header:        header,
+		Timestamp:     time.Now(),
+	}, nil
+}
+
+// contextReader fails once ctx is done, so that a request abandoned by do stops
+// reading the body stream of the caller. A read already in progress is not interrupted.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// ------------------------------------------------------------------ net/http
+
+type httpTransport struct {
+	client *http.Client
+}
+
+// NewHTTPTransport returns a transport that sends requests with the net/http
+// client, http.DefaultClient if nil. Files are downloaded with the same client.
+func NewHTTPTransport(client *http.Client) Transport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpTransport{client: client}
+}
+
+func (t *httpTransport) Do(ctx context.Context, r *HTTPRequest) (*APIResponse, error) {
+	body := r.Body
+	if body == nil || r.ContentLength == 0 {
+		body = http.NoBody
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header.Clone()
+	if body != http.NoBody {
+		req.ContentLength = r.ContentLength
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// The URL in *url.Error contains the token.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, urlErr.Err
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIResponse{
+		StatusCode:    resp.StatusCode,
+		StatusMessage: []byte(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode)+" ")),
+		Body:          data,
+		Header:        resp.Header,
+		Timestamp:     time.Now(),
+	}, nil
+}
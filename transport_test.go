@@ -0,0 +1,119 @@
+package greenapi_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/greenapitest"
+)
+
+// endlessReader counts the reads of an endless body.
+type endlessReader struct {
+	reads atomic.Int64
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	r.reads.Add(1)
+	time.Sleep(time.Millisecond)
+	clear(p)
+	return len(p), nil
+}
+
+func TestTransportStopsReadingBodyAfterCancel(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		transport greenapi.Transport
+	}{
+		{"fasthttp", greenapi.NewFastHTTPTransport(nil)},
+		{"net/http", greenapi.NewHTTPTransport(nil)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := greenapitest.NewServer()
+			defer server.Close()
+			GreenAPI := newGreenAPI(t, server, server.NewInstance(), greenapi.WithTransport(tt.transport))
+
+			// Without a deadline fasthttp cannot time out the request by itself.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			time.AfterFunc(100*time.Millisecond, cancel)
+
+			body := &endlessReader{}
+			_, err := GreenAPI.Sending().UploadFileReaderCtx(ctx, body, "endless.bin", -1)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("got %v, want context.Canceled", err)
+			}
+
+			// A read in progress may still finish.
+			time.Sleep(20 * time.Millisecond)
+			reads := body.reads.Load()
+			time.Sleep(100 * time.Millisecond)
+			if got := body.reads.Load(); got != reads {
+				t.Errorf("body read %d more times after the request returned", got-reads)
+			}
+		})
+	}
+}
+
+func TestTransportDeadlineSetsContextError(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+	GreenAPI := newGreenAPI(t, server, instance)
+
+	server.Inject("receiveNotification", greenapitest.Hang().Always())
+
+	for range 5 {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err := GreenAPI.Receiving().ReceiveNotificationCtx(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) || ctx.Err() == nil {
+			t.Errorf("got %v with ctx.Err() = %v, want both context.DeadlineExceeded", err, ctx.Err())
+		}
+		cancel()
+	}
+}
+
+// recordingTransport records the URLs of the requests sent with the net/http client.
+type recordingTransport struct {
+	mu   sync.Mutex
+	urls []string
+}
+
+func (t *recordingTransport) Do(ctx context.Context, req *greenapi.HTTPRequest) (*greenapi.APIResponse, error) {
+	t.mu.Lock()
+	t.urls = append(t.urls, req.URL)
+	t.mu.Unlock()
+	return greenapi.NewHTTPTransport(nil).Do(ctx, req)
+}
+
+func TestCustomTransportDownloadsFiles(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+
+	transport := &recordingTransport{}
+	GreenAPI := newGreenAPI(t, server, instance, greenapi.WithTransport(transport))
+
+	content := bytes.Repeat([]byte("file content "), 1000)
+	idMessage := instance.ReceiveFile("10000000@c.us", "", "file.txt", content)
+
+	buffer := &bytes.Buffer{}
+	file, err := GreenAPI.Receiving().DownloadFileTo(context.Background(), "10000000@c.us", idMessage, buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), content) || file.Size != int64(len(content)) || file.FileName != "file.txt" {
+		t.Errorf("downloaded %d bytes of %s, want %d bytes of file.txt", file.Size, file.FileName, len(content))
+	}
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.urls) != 2 || transport.urls[1] != file.DownloadUrl {
+		t.Errorf("transport sent %q, want downloadFile and %s", transport.urls, file.DownloadUrl)
+	}
+}
@@ -2,6 +2,7 @@ package greenapi
 
 import (
 	"encoding/json"
+	"net/http"
 	"time"
 )
 
@@ -10,6 +11,8 @@ type GreenAPI struct {
 	MediaURL         string
 	IDInstance       string
 	APITokenInstance string
+
+	client *apiClient
 }
 
 type GreenAPIInterface interface {
@@ -19,15 +22,43 @@ type GreenAPIInterface interface {
 type GreenAPIPartner struct {
 	PartnerToken string
 	Email        string
+	// URL of the partner methods, https://api.green-api.com/v3/partner if empty.
+	PartnerURL string
+
+	client *apiClient
 }
 
 type GreenAPIPartnerInterface interface {
-	PartnerRequest(HTTPMethod, APIMethod string, requestBody []byte) (*APIResponse, error)
+	PartnerRequest(HTTPMethod, APIMethod string, requestBody []byte, options ...requestOptions) (*APIResponse, error)
 }
 
 type APIResponse struct {
 	StatusCode    int             `json:"status_code"`
 	StatusMessage []byte          `json:"status_message"`
 	Body          json.RawMessage `json:"body"`
+	Header        http.Header     `json:"header,omitempty"`
 	Timestamp     time.Time       `json:"timestamp"`
 }
+
+// Decode unmarshals the body of a successful response into v.
+// A response with a non-2xx status code is returned as an *APIError.
+func (r *APIResponse) Decode(v any) error {
+	if apiErr := newAPIError("", r); apiErr != nil {
+		return apiErr
+	}
+	return json.Unmarshal(r.Body, v)
+}
+
+// Decode converts the result of any method into its typed response, for example:
+//
+//	msg, err := greenapi.Decode[greenapi.ResponseSendMessage](GreenAPI.Sending().SendMessage("10000000", "Hello"))
+func Decode[T any](r *APIResponse, err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	v := new(T)
+	if err := r.Decode(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -3,27 +3,25 @@ package greenapi
 import (
 	"fmt"
 	"net/url"
-	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// ValidateChatId checks the chat ids with ParseChatId.
 func ValidateChatId(chatId ...string) error {
-
 	for _, chat := range chatId {
-		// Check for lid
-		_, err := strconv.Atoi(chat)
-		if err != nil {
-			// Not lid, using old check
-			if !strings.HasSuffix(chat, "@c.us") && !strings.HasSuffix(chat, "@g.us") {
-				return fmt.Errorf("chatId must end with \"@c.us\" or \"@g.us\"\ngot %s instead", chat)
-			}
+		if _, err := ParseChatId(chat); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// ValidateMessageLength checks that the message has at most limit characters.
+// Characters are counted as Unicode code points, as the API counts them.
 func ValidateMessageLength(message string, limit int) error {
-	if len(message) > limit {
+	if utf8.RuneCountInString(message) > limit {
 		return fmt.Errorf("length of the message exceeds the limit of %v", limit)
 	}
 	return nil
@@ -36,3 +34,73 @@ func ValidateURL(link string) error {
 	}
 	return nil
 }
+
+// SplitMessage splits the message into parts of at most limit characters
+// (Unicode code points). A part ends at the last paragraph break within the limit,
+// or else at the last line break, sentence end, space or, for text without them,
+// at the limit. Whitespace between parts is dropped, the order of the text is kept.
+func SplitMessage(message string, limit int) []string {
+	if limit <= 0 {
+		return []string{message}
+	}
+
+	var parts []string
+	rest := message
+	for utf8.RuneCountInString(rest) > limit {
+		cut := splitIndex(rest, limit)
+		if part := strings.TrimRightFunc(rest[:cut], unicode.IsSpace); part != "" {
+			parts = append(parts, part)
+		}
+		rest = strings.TrimLeftFunc(rest[cut:], unicode.IsSpace)
+	}
+	if rest != "" || len(parts) == 0 {
+		parts = append(parts, rest)
+	}
+	return parts
+}
+
+// splitIndex returns the byte index to end the next part of s at.
+// Boundaries in the first half of the limit are ignored, so that parts are not too short.
+func splitIndex(s string, limit int) int {
+	// The byte indexes after the first limit/2 and limit runes.
+	minimum, end := 0, 0
+	for i := 0; i < limit; i++ {
+		if i == limit/2 {
+			minimum = end
+		}
+		_, size := utf8.DecodeRuneInString(s[end:])
+		end += size
+	}
+	window := s[:end]
+
+	if i := strings.LastIndex(window, "\n\n"); i > 0 && i >= minimum {
+		return i + 2
+	}
+	if i := strings.LastIndex(window, "\n"); i > 0 && i >= minimum {
+		return i + 1
+	}
+	if i := lastSentenceEnd(window); i > 0 && i >= minimum {
+		return i
+	}
+	if i := strings.LastIndexFunc(window, unicode.IsSpace); i > 0 && i >= minimum {
+		return i
+	}
+	return end
+}
+
+// lastSentenceEnd returns the byte index after the last sentence ending
+// punctuation followed by whitespace, or -1.
+func lastSentenceEnd(s string) int {
+	for i := len(s); i > 0; {
+		r, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+		if !unicode.IsSpace(r) {
+			continue
+		}
+		switch prev, _ := utf8.DecodeLastRuneInString(s[:i]); prev {
+		case '.', '!', '?', '…':
+			return i
+		}
+	}
+	return -1
+}
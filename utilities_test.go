@@ -0,0 +1,78 @@
+package greenapi_test
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	greenapi "github.com/green-api/max-api-client-golang"
+	"github.com/green-api/max-api-client-golang/greenapitest"
+)
+
+func TestSplitMessage(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		message string
+		limit   int
+		want    []string
+	}{
+		{"short", "Hello", 10, []string{"Hello"}},
+		{"empty", "", 10, []string{""}},
+		{"no limit", "Hello, world", 0, []string{"Hello, world"}},
+		{"paragraph", "First paragraph.\n\nSecond one.", 20, []string{"First paragraph.", "Second one."}},
+		{"line", "First line\nSecond line", 15, []string{"First line", "Second line"}},
+		{"sentence", "One two. Three four five", 15, []string{"One two.", "Three four five"}},
+		{"space", "aaaa bbbb cccc", 10, []string{"aaaa bbbb", "cccc"}},
+		{"no boundaries", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"code points", "приветмир", 3, []string{"при", "вет", "мир"}},
+		{"short boundary ignored", "a bcdefghij", 6, []string{"a bcde", "fghij"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := greenapi.SplitMessage(tt.message, tt.limit); !slices.Equal(got, tt.want) {
+				t.Errorf("SplitMessage(%q, %d) = %q, want %q", tt.message, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendLongMessage(t *testing.T) {
+	server := greenapitest.NewServer()
+	defer server.Close()
+	instance := server.NewInstance()
+	GreenAPI := newGreenAPI(t, server, instance)
+
+	want := []string{strings.Repeat("a", 12000), strings.Repeat("b", 12000), strings.Repeat("c", 12000)}
+	responses, err := GreenAPI.Sending().SendLongMessage("10000000", strings.Join(want, "\n\n"),
+		greenapi.OptionalQuotedMessageId("BAE5000000000001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != len(want) {
+		t.Fatalf("%d responses, want %d", len(responses), len(want))
+	}
+
+	var parts []string
+	for i, r := range server.Requests() {
+		var body greenapi.RequestSendMessage
+		if err := json.Unmarshal(r.Body, &body); err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, body.Message)
+		if wantQuoted := i == 0; (body.QuotedMessageId == "BAE5000000000001") != wantQuoted {
+			t.Errorf("part %d quotes %q, want quoting only in the first part", i, body.QuotedMessageId)
+		}
+	}
+	if !slices.Equal(parts, want) {
+		t.Errorf("sent %d parts, want %d parts in order", len(parts), len(want))
+	}
+
+	for _, message := range []string{"", " \n\n "} {
+		if _, err := GreenAPI.Sending().SendLongMessage("10000000", message); err == nil {
+			t.Errorf("SendLongMessage(%q) did not return an error", message)
+		}
+	}
+	if n := len(server.Requests()); n != len(want) {
+		t.Errorf("%d requests, want no requests for empty messages", n)
+	}
+}